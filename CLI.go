@@ -20,11 +20,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/ignalina/fixed2arrow/impl"
 	"io"
 	"os"
+	"strings"
 
 	"time"
 )
@@ -36,30 +38,60 @@ import (
 func main() {
 	start := time.Now()
 
+	output := flag.String("o", "", "parquet file to write, default the first input with .parqet")
+	sourceFile := flag.String("source-file", "", "name of a column with the file of each row")
+	sourceEncoding := flag.String("encoding", "", "encoding of the input, like windows-1252, cp850 or shift_jis")
+	sniff := flag.Bool("sniff", false, "detect the line terminator and encoding of the input")
+	compression := flag.String("compression", "auto", "compression of the input: auto, none, gzip, zstd, bzip2, xz or lz4")
+	flag.Parse()
+
+	// The files or globs are given as arguments, - reads stdin of unknown size
 	fullPath := "test.last10"
+	if flag.NArg() > 0 {
+		fullPath = flag.Arg(0)
+	}
 
 	fixedRow := createFixedRow()
 
-	var reader *io.Reader
-	file, err := os.Open(fullPath)
-	fi, _ := file.Stat()
+	var fst impl.FixedSizeTable
+	fst.Cores = 8
+	fst.SourceFileColumn = *sourceFile
+	fst.SniffInput = *sniff
+	fst.SourceEncoding = *sourceEncoding
 
-	if err != nil {
+	var err error
+	fst.Compression, err = impl.ParseCompression(*compression)
+	if nil != err {
+		fmt.Println("BAD!!!", err)
 		return
 	}
-	defer file.Close()
-	*reader = file
 
-	var fst impl.FixedSizeTable
-	fst.Cores = 8
-	fst.ColumnsizeCap = 3000000 / fst.Cores
-	err = impl.CreateFixedSizeTableFromFile(&fst, &fixedRow, reader, fi.Size())
+	if flag.NArg() > 1 || strings.ContainsAny(fullPath, "*?[") {
+		var files []string
+		files, err = impl.ExpandGlobs(flag.Args())
+		if nil != err {
+			fmt.Println("BAD!!!", err)
+			return
+		}
+		fullPath = files[0]
+		err = impl.CreateFixedSizeTableFromFiles(&fst, &fixedRow, files)
+	} else {
+		err = convertFile(&fst, &fixedRow, fullPath)
+	}
 	if nil != err {
-		fmt.Println("BAD!!!")
+		fmt.Println("BAD!!!", err)
+		return
+	}
+	if nil != fst.Sniffed {
+		fmt.Printf("sniffed terminator=%d encoding=%q record length=%d\n", fst.Sniffed.LineTerminator, fst.Sniffed.Encoding, fst.Sniffed.RecordLength)
+	}
+
+	if "" == *output {
+		*output = fullPath + ".parqet"
 	}
 
 	var outFile *os.File
-	outFile, err = os.OpenFile(fullPath+".parqet", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	outFile, err = os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 	if impl.IsError(err) {
 		return
 	}
@@ -73,12 +105,37 @@ func main() {
 
 }
 
+// convertFile converts one file, or stdin for -.
+func convertFile(fst *impl.FixedSizeTable, fixedRow *impl.FixedRow, fullPath string) error {
+	var reader io.Reader = os.Stdin
+	var size int64 = -1
+	if "-" == fullPath {
+		fullPath = "stdin"
+	} else {
+		file, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		fi, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		reader = file
+		size = fi.Size()
+	}
+
+	fst.SourceName = fullPath
+	return impl.CreateFixedSizeTableFromFile(fst, fixedRow, &reader, size)
+}
+
 // Example table with 2 columns ,this should constructed from a schema in follow versions
 func createFixedRow() impl.FixedRow {
 	result := impl.FixedRow{
-		[]impl.FixedField{
-			impl.FixedField{11, arrow.Field{Name: "idnr", Type: arrow.PrimitiveTypes.Int64}, arrow.PrimitiveTypes.Int64, 0},
-			impl.FixedField{20, arrow.Field{Name: "description", Type: arrow.BinaryTypes.String}, arrow.BinaryTypes.String, 0},
+		FixedField: []impl.FixedField{
+			{Len: 11, DestinField: arrow.Field{Name: "idnr", Type: arrow.PrimitiveTypes.Int64}, SourceType: arrow.PrimitiveTypes.Int64},
+			{Len: 20, DestinField: arrow.Field{Name: "description", Type: arrow.BinaryTypes.String}, SourceType: arrow.BinaryTypes.String},
 		},
 	}
 	return result
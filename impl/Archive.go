@@ -0,0 +1,187 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+// ArchiveLayout converts the archive members matching Pattern with Row. A Pattern without a slash is matched
+// against the base name of the member. Table holds the settings, it is copied for every member and may be nil.
+type ArchiveLayout struct {
+	Pattern string
+	Row     *FixedRow
+	Table   *FixedSizeTable
+}
+
+// ArchiveOutput gets the table of every converted member, its records are released when it returns.
+type ArchiveOutput func(member string, fst *FixedSizeTable) error
+
+// ConvertArchive converts the members of a zip, tar or compressed tar file matching a layout, without extracting
+// them. Members matching no layout, like a control file, are skipped.
+func ConvertArchive(ctx context.Context, name string, layouts []ArchiveLayout, output ArchiveOutput) error {
+	file, err := os.Open(name)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if nil != err {
+		return err
+	}
+
+	head := make([]byte, magicLength)
+	n, _ := file.ReadAt(head, 0)
+	if bytes.HasPrefix(head[:n], []byte("PK\x03\x04")) || strings.EqualFold(".zip", path.Ext(name)) {
+		return ConvertZip(ctx, file, fi.Size(), layouts, output)
+	}
+
+	// The decompression of a table does the tar file too
+	reader, closeReader, err := (&FixedSizeTable{Compression: CompressionAuto, SourceName: name, Cores: 1}).decompress(file)
+	if nil != err {
+		return err
+	}
+	defer closeReader()
+
+	return ConvertTar(ctx, reader, layouts, output)
+}
+
+// ConvertZip is ConvertArchive for a zip archive. Stored members are read in parallel regions.
+func ConvertZip(ctx context.Context, readerAt io.ReaderAt, size int64, layouts []ArchiveLayout, output ArchiveOutput) error {
+	zr, err := zip.NewReader(readerAt, size)
+	if nil != err {
+		return err
+	}
+
+	for _, f := range zr.File {
+		layout := matchLayout(layouts, f.Name)
+		if nil == layout || f.FileInfo().IsDir() {
+			continue
+		}
+
+		fst := layout.newTable(f.Name)
+		if zip.Store == f.Method {
+			offset, err := f.DataOffset()
+			if nil != err {
+				return err
+			}
+			section := io.NewSectionReader(readerAt, offset, int64(f.UncompressedSize64))
+			err = CreateFixedSizeTableFromReaderAtContext(ctx, fst, layout.Row, section, section.Size())
+		} else {
+			var rc io.ReadCloser
+			rc, err = f.Open()
+			if nil != err {
+				return err
+			}
+			err = CreateFixedSizeTableFromStreamContext(ctx, fst, layout.Row, rc)
+			rc.Close()
+		}
+
+		err = fst.outputMember(f.Name, err, output)
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConvertTar is ConvertArchive for a tar stream, compression already taken off.
+func ConvertTar(ctx context.Context, reader io.Reader, layouts []ArchiveLayout, output ArchiveOutput) error {
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if io.EOF == err {
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+
+		layout := matchLayout(layouts, header.Name)
+		if nil == layout || tar.TypeReg != header.Typeflag {
+			continue
+		}
+
+		fst := layout.newTable(header.Name)
+		err = CreateFixedSizeTableFromStreamContext(ctx, fst, layout.Row, tr)
+		err = fst.outputMember(header.Name, err, output)
+		if nil != err {
+			return err
+		}
+	}
+}
+
+func matchLayout(layouts []ArchiveLayout, member string) *ArchiveLayout {
+	for i := range layouts {
+		name := member
+		if !strings.Contains(layouts[i].Pattern, "/") {
+			name = path.Base(member)
+		}
+
+		if ok, _ := path.Match(layouts[i].Pattern, name); ok {
+			return &layouts[i]
+		}
+	}
+	return nil
+}
+
+func (layout *ArchiveLayout) newTable(member string) *FixedSizeTable {
+	if nil == layout.Table {
+		return &FixedSizeTable{SourceName: member, Cores: 1}
+	}
+	// The layout table is never prepared, the FindLastNL it holds is the given one
+	table := layout.Table.newSource(member)
+	table.FindLastNL = layout.Table.FindLastNL
+	return table
+}
+
+// outputMember hands the table of a converted member to output and releases its records.
+func (fst *FixedSizeTable) outputMember(member string, err error, output ArchiveOutput) error {
+	if nil != err {
+		return &MemberError{Member: member, Err: err}
+	}
+	defer fst.releaseRecords()
+
+	return output(member, fst)
+}
+
+func (fst *FixedSizeTable) releaseRecords() {
+	for _, records := range fst.Records {
+		for _, record := range records {
+			record.Release()
+		}
+	}
+	fst.Records = nil
+	for _, record := range []arrow.Record{fst.HeaderRecord, fst.FooterRecord} {
+		if nil != record {
+			record.Release()
+		}
+	}
+	fst.HeaderRecord, fst.FooterRecord = nil, nil
+}
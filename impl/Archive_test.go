@@ -0,0 +1,189 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+type archiveMember struct {
+	name string
+	data []byte
+}
+
+func archiveMembers() []archiveMember {
+	data := benchData()
+	lineLength := len(data) / benchLines
+	return []archiveMember{
+		{"data/a.txt", data[:3000*lineLength]},
+		{"control.ctl", []byte("3000 7000\n")},
+		{"data/b.txt", data[3000*lineLength:]},
+	}
+}
+
+func writeZip(t *testing.T, file string, members []archiveMember) {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for i, m := range members {
+		method := zip.Deflate
+		if 0 == i { // Stored members are read in parallel regions
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: m.name, Method: method})
+		if nil != err {
+			t.Fatal(err)
+		}
+		w.Write(m.data)
+	}
+	if err := zw.Close(); nil != err {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, b.Bytes(), 0644); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGz(t *testing.T, file string, members []archiveMember) {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(zw)
+	tw.WriteHeader(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, m := range members {
+		if err := tw.WriteHeader(&tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(m.data))}); nil != err {
+			t.Fatal(err)
+		}
+		tw.Write(m.data)
+	}
+	tw.Close()
+	zw.Close()
+	if err := os.WriteFile(file, b.Bytes(), 0644); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertArchive(t *testing.T) {
+	dir := t.TempDir()
+	zipFile, tarFile := filepath.Join(dir, "feed.zip"), filepath.Join(dir, "feed.tgz")
+	writeZip(t, zipFile, archiveMembers())
+	writeTarGz(t, tarFile, archiveMembers())
+
+	tests := []struct {
+		pattern string
+		members []string
+		lines   []int
+	}{
+		{"*.txt", []string{"data/a.txt", "data/b.txt"}, []int{3000, 7000}},
+		{"data/b.txt", []string{"data/b.txt"}, []int{7000}},
+		{"b.txt", []string{"data/b.txt"}, []int{7000}},
+		{"*.csv", nil, nil},
+	}
+
+	for _, file := range []string{zipFile, tarFile} {
+		for _, tt := range tests {
+			layouts := []ArchiveLayout{{Pattern: tt.pattern, Row: benchRow(), Table: &FixedSizeTable{Cores: 2, SourceFileColumn: "file"}}}
+
+			var members []string
+			err := ConvertArchive(context.Background(), file, layouts, func(member string, fst *FixedSizeTable) error {
+				if len(members) < len(tt.lines) && tt.lines[len(members)] != fst.LinesParsed {
+					t.Errorf("%s %s: %s has %d lines", file, tt.pattern, member, fst.LinesParsed)
+				}
+				record := fst.Records[0][0]
+				if got := dictionaryString(record.Column(int(record.NumCols())-1), 0); got != member {
+					t.Errorf("%s %s: source file %s, want %s", file, tt.pattern, got, member)
+				}
+				members = append(members, member)
+				return nil
+			})
+			if nil != err {
+				t.Errorf("%s %s: %v", file, tt.pattern, err)
+			}
+			if len(members) != len(tt.members) {
+				t.Errorf("%s %s: converted %q, want %q", file, tt.pattern, members, tt.members)
+				continue
+			}
+			for i := range members {
+				if members[i] != tt.members[i] {
+					t.Errorf("%s %s: converted %q, want %q", file, tt.pattern, members, tt.members)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertArchiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	members := archiveMembers()
+	members = append(members, archiveMember{"data/c.txt", []byte("short\r\n")})
+	zipFile, tarFile := filepath.Join(dir, "feed.zip"), filepath.Join(dir, "feed.tar.gz")
+	writeZip(t, zipFile, members)
+	writeTarGz(t, tarFile, members)
+
+	for _, file := range []string{zipFile, tarFile} {
+		layouts := []ArchiveLayout{{Pattern: "*.txt", Row: benchRow()}}
+		converted := 0
+		err := ConvertArchive(context.Background(), file, layouts, func(member string, fst *FixedSizeTable) error {
+			converted++
+			return nil
+		})
+		var memberError *MemberError
+		if !errors.As(err, &memberError) || "data/c.txt" != memberError.Member || 2 != converted {
+			t.Errorf("%s: got %v after %d members, want a MemberError of data/c.txt", file, err, converted)
+		}
+
+		// An error of the output stops the conversion as it is
+		stop := errors.New("stop")
+		err = ConvertArchive(context.Background(), file, layouts, func(member string, fst *FixedSizeTable) error {
+			return stop
+		})
+		if stop != err {
+			t.Errorf("%s: got %v, want %v", file, err, stop)
+		}
+	}
+}
+
+// TestConvertTar reads a tar stream without compression.
+func TestConvertTar(t *testing.T) {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, m := range archiveMembers() {
+		tw.WriteHeader(&tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(m.data))})
+		tw.Write(m.data)
+	}
+	tw.Close()
+
+	lines := 0
+	layouts := []ArchiveLayout{{Pattern: "*.txt", Row: benchRow()}}
+	err := ConvertTar(context.Background(), io.Reader(&b), layouts, func(member string, fst *FixedSizeTable) error {
+		lines += fst.LinesParsed
+		return nil
+	})
+	if nil != err || benchLines != lines {
+		t.Errorf("got %v and %d lines of %d", err, lines, benchLines)
+	}
+}
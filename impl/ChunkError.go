@@ -0,0 +1,136 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChunkError is the failure of one chunk. Line counts from 1 in the input, the header included, 0 when no line is
+// to blame.
+type ChunkError struct {
+	Chunk int
+	Line  int
+	Err   error
+}
+
+func (e *ChunkError) Error() string {
+	if 0 == e.Line {
+		return fmt.Sprintf("chunk %d: %v", e.Chunk, e.Err)
+	}
+	return fmt.Sprintf("chunk %d line %d: %v", e.Chunk, e.Line, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkErrors joins the errors of all failed chunks.
+type ChunkErrors []*ChunkError
+
+func (e ChunkErrors) Error() string {
+	return joinErrors(e)
+}
+
+func (e ChunkErrors) Unwrap() []error {
+	return unwrapErrors(e)
+}
+
+// As lets errors.As look into every chunk, also with a Go release not knowing Unwrap() []error.
+func (e ChunkErrors) As(target interface{}) bool {
+	return asErrors(e, target)
+}
+
+// PanicError is a panic recovered in a worker.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// MemberError is the failure of an archive member.
+type MemberError struct {
+	Member string
+	Err    error
+}
+
+func (e *MemberError) Error() string {
+	return e.Member + ": " + e.Err.Error()
+}
+
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// ControlError is a failed control of the header or footer against the body.
+type ControlError struct {
+	Control Control
+	Want    string // What the body adds up to, or the Value of the Control
+	Got     string // What the header or footer holds
+}
+
+func (e *ControlError) Error() string {
+	return fmt.Sprintf("control %s is %s, want %s", e.Control.Field, e.Got, e.Want)
+}
+
+// ControlErrors joins the errors of all failed controls.
+type ControlErrors []*ControlError
+
+func (e ControlErrors) Error() string {
+	return joinErrors(e)
+}
+
+func (e ControlErrors) Unwrap() []error {
+	return unwrapErrors(e)
+}
+
+// As lets errors.As look into every control, also with a Go release not knowing Unwrap() []error.
+func (e ControlErrors) As(target interface{}) bool {
+	return asErrors(e, target)
+}
+
+// joinErrors gives the messages of errs, one per line.
+func joinErrors[E error](errs []E) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func unwrapErrors[E error](errs []E) []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+func asErrors[E error](errs []E, target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
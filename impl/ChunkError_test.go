@@ -0,0 +1,140 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+func chunkErrorRow() *FixedRow {
+	return &FixedRow{FixedField: []FixedField{
+		{Len: 5, DestinField: arrow.Field{Name: "value", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+}
+
+// chunkErrorData gives lines of 5 bytes, short ones at the given line numbers counting from 1.
+func chunkErrorData(lines int, short ...int) []byte {
+	var b bytes.Buffer
+	for i := 1; i <= lines; i++ {
+		line := "abcde"
+		for _, s := range short {
+			if s == i {
+				line = "ab"
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.Bytes()
+}
+
+func chunkErrorConvert(engine string, fst *FixedSizeTable, data []byte, row *FixedRow) error {
+	switch engine {
+	case "readerAt":
+		return CreateFixedSizeTableFromReaderAt(fst, row, bytes.NewReader(data), int64(len(data)))
+	case "stream":
+		if 0 == fst.StreamChunkSize {
+			fst.StreamChunkSize = 64
+		}
+		return CreateFixedSizeTableFromStreamContext(context.Background(), fst, row, bytes.NewReader(data))
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	return CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(data)))
+}
+
+func TestChunkErrorLine(t *testing.T) {
+	tests := []struct {
+		short []int
+	}{
+		{[]int{3}},
+		{[]int{90}},
+		{[]int{3, 90}},  // The first chunk stops at its failure
+		{[]int{51, 52}}, // Around a chunk boundary
+	}
+
+	for _, engine := range []string{"file", "readerAt", "stream"} {
+		for _, tt := range tests {
+			fst := &FixedSizeTable{Cores: 4, LineTerminator: TerminatorLF}
+			err := chunkErrorConvert(engine, fst, chunkErrorData(100, tt.short...), chunkErrorRow())
+
+			var chunkErrors ChunkErrors
+			if !errors.As(err, &chunkErrors) {
+				t.Errorf("%s %v: got %v, want ChunkErrors", engine, tt.short, err)
+				continue
+			}
+			var lines []int
+			for _, e := range chunkErrors {
+				lines = append(lines, e.Line)
+			}
+			// A chunk stops at its first failure
+			want := map[int]bool{}
+			for _, s := range tt.short {
+				want[s] = true
+			}
+			for _, line := range lines {
+				if !want[line] {
+					t.Errorf("%s %v: error at line %d, chunk errors %v", engine, tt.short, line, err)
+				}
+			}
+			if 0 == len(lines) || lines[0] != tt.short[0] {
+				t.Errorf("%s %v: errors at lines %v", engine, tt.short, lines)
+			}
+
+			var chunkError *ChunkError
+			if !errors.As(err, &chunkError) || chunkError.Line != tt.short[0] {
+				t.Errorf("%s %v: errors.As gives %v", engine, tt.short, chunkError)
+			}
+			if !strings.Contains(err.Error(), "shorter than the row") {
+				t.Errorf("%s %v: message %q", engine, tt.short, err.Error())
+			}
+			if nil != fst.Records {
+				t.Errorf("%s %v: failed conversion kept records", engine, tt.short)
+			}
+		}
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	data := chunkErrorData(100)
+	data[6*70] = 'X'
+
+	fst := &FixedSizeTable{Cores: 4, LineTerminator: TerminatorLF}
+	fst.ConsumeBytesFunc = func(line []byte, fstc *FixedSizeTableChunk) {
+		if 'X' == line[0] {
+			panic("bad line")
+		}
+		ConsumeBytes(line, fstc)
+	}
+	err := chunkErrorConvert("file", fst, data, chunkErrorRow())
+
+	var panicError *PanicError
+	if !errors.As(err, &panicError) || "bad line" != panicError.Value {
+		t.Fatalf("got %v, want a PanicError", err)
+	}
+	var chunkError *ChunkError
+	if !errors.As(err, &chunkError) || 71 != chunkError.Line {
+		t.Errorf("got %v, want the panic at line 71", err)
+	}
+}
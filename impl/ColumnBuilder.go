@@ -22,6 +22,7 @@ package impl
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -29,23 +30,26 @@ import (
 	"github.com/apache/arrow/go/v13/arrow/array"
 	"github.com/apache/arrow/go/v13/arrow/ipc"
 	"github.com/apache/arrow/go/v13/arrow/memory"
-	"golang.org/x/exp/maps"
-	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
 	"golang.org/x/xerrors"
 	"io"
 	"os"
+	"regexp"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
 
 type FixedField struct {
-	Len         int
-	DestinField arrow.Field
-	SourceType  arrow.DataType
-	TableId     int
+	Len          int
+	DestinField  arrow.Field
+	SourceType   arrow.DataType
+	TableId      int
+	Boolean      *BooleanFormat
+	NumberLocale *NumberLocale
+	BinaryFormat BinaryFormat
+	Builder      ColumnBuilderFactory // Overrides the Registry for this field
 }
 
 type FixedRow struct {
@@ -59,8 +63,29 @@ type FixedSizeTableChunk struct {
 	RecordBuilder  []*array.RecordBuilder
 	Record         []arrow.Record
 	Bytes          []byte
+	RawLine        []byte
+
+	decoder       *encoding.Decoder
+	byteTable     *byteTable
+	fieldBuf      []byte
+	lineBuf       []byte
+	textBuf       []byte
+	rawBuilders   []RawColumnBuilder
+	bytesBuilders []BytesColumnBuilder
+	errBuilders   []ErrColumnBuilder
+	reservedRows  int
+	lineage       []lineageBuilders
+	rowHash       []array.Builder
+	hashBuf       []byte
+	offset        int64 // Of the chunk in the input
+	lines         int   // Scanned, the header included
 
 	LinesParsed       int
+	ShortLines        int
+	LongLines         int
+	RejectedLines     int
+	SkippedLines      int
+	Err               ChunkErrors
 	DurationReadChunk time.Duration
 	DurationToArrow   time.Duration
 	DurationToExport  time.Duration
@@ -76,20 +101,70 @@ type FixedSizeTable struct {
 	wg                   *sync.WaitGroup
 	Records              [][]arrow.Record
 	TableColAmount       []int
-	Header               string
-	Footer               string
+	Header               string   // The first line of the header
+	Footer               string   // The last line of the footer
+	Headers              []string // All lines of the header
+	Footers              []string // All lines of the footer
 	HasHeader            bool
 	HasFooter            bool
+	HeaderLines          int            // Lines of the header, 0 means 1
+	FooterLines          int            // Lines of the footer, 0 means 1
+	HeaderPattern        *regexp.Regexp // Instead of HeaderLines the header is the first lines matching
+	FooterPattern        *regexp.Regexp // Instead of FooterLines the footer is the last lines matching
+	SkipBlankLines       bool           // Skip lines of only spaces anywhere
+	CommentPrefix        string         // Skip lines starting with it anywhere, empty for none
+	HeaderRow            *FixedRow      // Layout of the first header line, parsed into HeaderRecord
+	FooterRow            *FixedRow      // Layout of the last footer line, parsed into FooterRecord
+	Controls             []Control      // Checks of the header and footer against the body, failures give ControlErrors
 	CalcHash             bool
 	SourceEncoding       string
 	ConsumeLineFunc      func(line string, fstc *FixedSizeTableChunk)
-	FindLastNL           func(bytes []byte) int
+	ConsumeBytesFunc     func(line []byte, fstc *FixedSizeTableChunk)
+	FindLastNL           func(bytes []byte) int // Where a chunk ends, nil to follow the LineTerminator
+	LineTerminator       LineTerminator
+	RecordLength         int  // Bytes per line with TerminatorFixed, 0 means the length of the row
+	SniffInput           bool // Detect the LineTerminator, SourceEncoding and byte order mark when not set
 	CustomParams         interface{}
+	NumberLocale         *NumberLocale
+	MaxRecordRows        int // Cut chunks into records of at most this many rows, 0 for one record per chunk
+	ShortLinePolicy      ShortLinePolicy
+	Compression          Compression
+	SourceName           string // Name of the input, its extension may tell the compression
+	StreamChunkSize      int    // Bytes per chunk of a stream, 0 means 16 MiB
+	SourceFileColumn     string // Name of a column with the file of each row, short for Lineage.SourceFile
+	Lineage              Lineage
+	RowHash              RowHash
+	ColumnChecksums      bool // Compute Checksums
+	Sources              []*FixedSizeTable
+	LongLinePolicy       LongLinePolicy
 	CustomColumnBuilders map[arrow.Type]func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder
+	Registry             *Registry // nil means DefaultRegistry
+	registry             *Registry
+	ctx                  context.Context
+	pool                 chan struct{} // Workers shared by several tables
+	ingestedAt           time.Time
+	rowHashRanges        [][2]int
+	terminator           []byte // Of the LineTerminator in the SourceEncoding, nil for TerminatorDefault unless UTF-16
+	trimCR               []byte // With TerminatorDefault in UTF-16, the CR left out before the terminator
+	unit                 int    // Bytes of a code unit of the SourceEncoding, terminators are only found at whole units
+	recordLength         int    // With TerminatorFixed
+	bomLength            int
+	encoding             encoding.Encoding // Of the SourceEncoding, nil for none
+	byteTable            *byteTable        // Of a single byte SourceEncoding, used instead of the decoder
+	space                []byte            // In the SourceEncoding, to pad short lines
+	givenFindLastNL      func(bytes []byte) int
 
 	Cores              int
 	LinesParsed        int
+	ShortLines         int
+	LongLines          int
+	RejectedLines      int // Left out by the line policies, included in LinesParsed
+	SkippedLines       int // Blank and comment lines, not in LinesParsed
 	Hash               []byte
+	Sniffed            *Sniffed         // With SniffInput
+	HeaderRecord       arrow.Record     // One row, its values are also in the schema metadata as header.<name>
+	FooterRecord       arrow.Record     // One row, its values are also in the schema metadata as footer.<name>
+	Checksums          []ColumnChecksum // Of every column of every table, when ColumnChecksums
 	DurationReadChunk  time.Duration
 	DurationToArrow    time.Duration
 	DurationToExport   time.Duration
@@ -99,82 +174,62 @@ type FixedSizeTable struct {
 
 //const columnsizeCap = 3000000
 
-var ColumnBuilders map[arrow.Type]func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder
+// DefaultRegistry holds the builders of all supported types, conversions without a Registry use it.
+var DefaultRegistry *Registry
 
 func init() {
 
-	ColumnBuilders = map[arrow.Type]func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder{
+	DefaultRegistry = NewRegistry(nil)
+	DefaultRegistry.builders = map[arrow.Type]ColumnBuilderFactory{
 		arrow.BinaryTypes.String.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderString{fixedField: fixedField, recordBuilder: builder, values: make([]string, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderString{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.StringBuilder), fieldnr: fieldNr}
 			return &result
 		},
 		arrow.PrimitiveTypes.Date32.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderDate32{fixedField: fixedField, recordBuilder: builder, values: make([]arrow.Date32, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderDate32{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.Date32Builder), fieldnr: fieldNr}
 			return &result
 		},
 		arrow.PrimitiveTypes.Date64.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderDate64{fixedField: fixedField, recordBuilder: builder, values: make([]arrow.Date64, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderDate64{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.Date64Builder), fieldnr: fieldNr}
 			return &result
 		},
-		arrow.PrimitiveTypes.Int8.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-			var result ColumnBuilder
-			result = &ColumnBuilderInt8{fixedField: fixedField, recordBuilder: builder, values: make([]int8, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
-			return &result
-		},
-		arrow.PrimitiveTypes.Int16.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-			var result ColumnBuilder
-			result = &ColumnBuilderInt16{fixedField: fixedField, recordBuilder: builder, values: make([]int16, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
-			return &result
-		},
-		arrow.PrimitiveTypes.Int32.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-			var result ColumnBuilder
-			result = &ColumnBuilderInt32{fixedField: fixedField, recordBuilder: builder, values: make([]int32, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
-			return &result
-		},
-		arrow.PrimitiveTypes.Int64.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-			var result ColumnBuilder
-			result = &ColumnBuilderInt64{fixedField: fixedField, recordBuilder: builder, values: make([]int64, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
-			return &result
-		},
-		arrow.PrimitiveTypes.Uint8.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-			var result ColumnBuilder
-			result = &ColumnBuilderUint8{fixedField: fixedField, recordBuilder: builder, values: make([]uint8, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
-			return &result
-		},
-		arrow.PrimitiveTypes.Uint16.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		arrow.FixedWidthTypes.Boolean.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderUint16{fixedField: fixedField, recordBuilder: builder, values: make([]uint16, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderBoolean{fixedField: fixedField, recordBuilder: builder, tokens: booleanTokens(fixedField.Boolean), builder: builder.Field(fieldNr).(*array.BooleanBuilder), fieldnr: fieldNr}
 			return &result
 		},
-		arrow.PrimitiveTypes.Uint32.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		arrow.BINARY: func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderUint32{fixedField: fixedField, recordBuilder: builder, values: make([]uint32, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderBinary{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.BinaryBuilder), fieldnr: fieldNr}
 			return &result
 		},
-		arrow.PrimitiveTypes.Uint64.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		arrow.LARGE_BINARY: func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderUint64{fixedField: fixedField, recordBuilder: builder, values: make([]uint64, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderLargeBinary{ColumnBuilderBinary{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.BinaryBuilder), fieldnr: fieldNr}}
 			return &result
 		},
-		arrow.PrimitiveTypes.Float32.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		arrow.FIXED_SIZE_BINARY: func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderFloat32{fixedField: fixedField, recordBuilder: builder, values: make([]float32, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderFixedSizeBinary{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.FixedSizeBinaryBuilder), byteWidth: fixedSizeBinaryWidth(fixedField), fieldnr: fieldNr}
 			return &result
 		},
-		arrow.PrimitiveTypes.Float64.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		arrow.DICTIONARY: func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderFloat64{fixedField: fixedField, recordBuilder: builder, values: make([]float64, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = &ColumnBuilderDictionary{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(*array.BinaryDictionaryBuilder), fieldnr: fieldNr}
 			return &result
 		},
-		arrow.FixedWidthTypes.Boolean.ID(): func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		ZONED_DECIMAL: func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
 			var result ColumnBuilder
-			result = &ColumnBuilderBoolean{fixedField: fixedField, recordBuilder: builder, values: make([]bool, 0, columnsizeCap), valid: make([]bool, 0, columnsizeCap), fieldnr: fieldNr}
+			result = newColumnBuilderZoned(fixedField, builder, fieldNr)
 			return &result
 		},
 	}
+
+	registerNumericBuilders(DefaultRegistry)
+	initColumnBuilders()
 }
 
 func (f FixedRow) CalRowLength() int {
@@ -186,31 +241,78 @@ func (f FixedRow) CalRowLength() int {
 	return sum + 2
 }
 
-func (f *FixedSizeTableChunk) createColumBuilders() bool {
+// Builders write straight into the arrow buffers, reserved for the rows expected in chunkBytes unless
+// ColumnsizeCap is set.
+func (f *FixedSizeTableChunk) createColumBuilders(chunkBytes int) bool {
 	f.ColumnBuilders = make([]ColumnBuilder, len(f.FixedSizeTable.Row.FixedField))
+	f.rawBuilders = make([]RawColumnBuilder, len(f.FixedSizeTable.Row.FixedField))
+	f.bytesBuilders = make([]BytesColumnBuilder, len(f.FixedSizeTable.Row.FixedField))
 
 	f.RecordBuilder = make([]*array.RecordBuilder, len(f.FixedSizeTable.Schema))
 
 	for i := 0; i < len(f.FixedSizeTable.TableColAmount); i++ {
 		f.RecordBuilder[i] = array.NewRecordBuilder(f.FixedSizeTable.mem, &f.FixedSizeTable.Schema[i])
 	}
+	f.reservedRows = f.FixedSizeTable.ColumnsizeCap
+	if 0 == f.reservedRows {
+		f.reservedRows = chunkBytes/f.FixedSizeTable.Row.CalRowLength() + 1
+	}
+	if f.FixedSizeTable.MaxRecordRows > 0 && f.FixedSizeTable.MaxRecordRows < f.reservedRows {
+		f.reservedRows = f.FixedSizeTable.MaxRecordRows
+	}
+
 	tableIndex := -1
 	tca := 0
 
 	var fieldNr int
-	for i, ff := range f.FixedSizeTable.Row.FixedField {
+	for i := range f.FixedSizeTable.Row.FixedField {
+		ff := &f.FixedSizeTable.Row.FixedField[i]
 		if 0 == tca {
 			tableIndex++
 			tca = f.FixedSizeTable.TableColAmount[tableIndex]
 			fieldNr = 0
 		}
-		f.ColumnBuilders[i] = *CreateColumBuilder(&ff, f.RecordBuilder[tableIndex], ff.Len, fieldNr, f.FixedSizeTable.ColumnsizeCap)
+		factory, _ := f.FixedSizeTable.registry.Lookup(ff)
+		f.ColumnBuilders[i] = *factory(ff, f.RecordBuilder[tableIndex], ff.Len, fieldNr, f.reservedRows)
+		f.rawBuilders[i], _ = f.ColumnBuilders[i].(RawColumnBuilder)
+		f.bytesBuilders[i], _ = f.ColumnBuilders[i].(BytesColumnBuilder)
+		if eb, ok := f.ColumnBuilders[i].(ErrColumnBuilder); ok {
+			f.errBuilders = append(f.errBuilders, eb)
+		}
 		tca--
 		fieldNr++
 	}
+	f.createLineageBuilders()
+	f.createRowHashBuilders()
+	f.reserve()
 	return true
 }
 
+func (f *FixedSizeTableChunk) reserve() {
+	ci := 0
+	for tableIndex, amount := range f.FixedSizeTable.TableColAmount {
+		for fieldNr := 0; fieldNr < amount; fieldNr++ {
+			b := f.RecordBuilder[tableIndex].Field(fieldNr)
+			b.Reserve(f.reservedRows)
+			if db, ok := b.(interface{ ReserveData(n int) }); ok {
+				db.ReserveData(f.reservedRows * f.FixedSizeTable.Row.FixedField[ci].Len)
+			}
+			ci++
+		}
+		for fieldNr := amount; fieldNr < len(f.RecordBuilder[tableIndex].Fields()); fieldNr++ {
+			f.RecordBuilder[tableIndex].Field(fieldNr).Reserve(f.reservedRows)
+		}
+	}
+}
+
+// newRecords cuts a record per table from what has been built so far. Record holds them table after table,
+// batch after batch.
+func (f *FixedSizeTableChunk) newRecords() {
+	for _, rb := range f.RecordBuilder {
+		f.Record = append(f.Record, rb.NewRecord())
+	}
+}
+
 func SaveFeather(w *os.File, fst *FixedSizeTable) error {
 	mem := memory.NewGoAllocator()
 
@@ -228,44 +330,108 @@ func SaveFeather(w *os.File, fst *FixedSizeTable) error {
 }
 
 // Read chunks of file and process them in go route after each chunk read. Slow disk is non non zero disk like sans etc
+// A size below 0 is unknown, like for stdin or a pipe, the reader is then streamed in chunks of StreamChunkSize.
 
 func CreateFixedSizeTableFromFile(fst *FixedSizeTable, row *FixedRow, reader *io.Reader, size int64) error {
+	return CreateFixedSizeTableFromFileContext(context.Background(), fst, row, reader, size)
+}
+
+// CreateFixedSizeTableFromFileContext is CreateFixedSizeTableFromFile stopping at the cancellation or deadline of ctx.
+// Reading stops, the workers abandon their chunks, everything built so far is released and ctx.Err() is returned.
+func CreateFixedSizeTableFromFileContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, reader *io.Reader, size int64) error {
+	if size < 0 {
+		return CreateFixedSizeTableFromStreamContext(ctx, fst, row, *reader)
+	}
+
+	// Compressed input has no known size, it is streamed
+	if CompressionNone != fst.Compression || fst.peeksHead() {
+		br := bufio.NewReaderSize(*reader, sniffLength)
+		head, _ := br.Peek(sniffLength)
+		if CompressionNone != fst.Compression && CompressionNone != fst.detectCompression(head) {
+			return CreateFixedSizeTableFromStreamContext(ctx, fst, row, br)
+		}
+		fst.sniff(head)
+
+		var r io.Reader = br
+		reader = &r
+	}
+
+	err := fst.prepare(ctx, row, size)
+	if nil != err {
+		return err
+	}
 
-	if nil == fst.FindLastNL && strings.ToLower(fst.SourceEncoding) == "utf-8" {
+	err = ParalizeChunks(fst, reader, size)
+	if nil != err {
+		return err
+	}
+
+	return nil
+}
+
+// prepare sets up fst for a conversion of size bytes of row.
+func (fst *FixedSizeTable) prepare(ctx context.Context, row *FixedRow, size int64) error {
+	fst.ctx = ctx
+
+	var err error
+	fst.encoding, err = LookupEncoding(fst.SourceEncoding)
+	if nil != err {
+		return err
+	}
+	fst.byteTable = newByteTable(fst.encoding)
+
+	fst.givenFindLastNL = fst.FindLastNL
+	fst.prepareTerminator(row)
+	if nil == fst.FindLastNL { // LF ends CRLF lines too
 		fst.FindLastNL = FindLastNL_NO_CR
-		fmt.Println("NO CR for eol")
-	} else if nil == fst.FindLastNL {
-		fmt.Println("NLCR for eol")
-		fst.FindLastNL = FindLastNLCR
 	}
 
 	if nil == fst.TableColAmount {
 		fst.TableColAmount = []int{len(row.FixedField)}
 	}
 
-	if nil == fst.ConsumeLineFunc {
-		fst.ConsumeLineFunc = ConsumeLine
+	// A custom ConsumeLineFunc keeps the string based path
+	if nil == fst.ConsumeLineFunc && nil == fst.ConsumeBytesFunc {
+		fst.ConsumeBytesFunc = ConsumeBytes
 	}
 
-	if nil != fst.CustomColumnBuilders {
-		maps.Copy(ColumnBuilders, fst.CustomColumnBuilders)
+	// The table locale goes on a copy of the row, the row may be shared by other conversions
+	if nil != fst.NumberLocale {
+		fields := append([]FixedField(nil), row.FixedField...)
+		for i := range fields {
+			if nil == fields[i].NumberLocale {
+				fields[i].NumberLocale = fst.NumberLocale
+			}
+		}
+		copied := *row
+		copied.FixedField = fields
+		row = &copied
 	}
 
-	if size < 20480 { // No multicore for silly small files.
+	fst.registry = fst.newRegistry()
+	if err := fst.registry.check(row); nil != err {
+		return err
+	}
+
+	if fst.Cores < 1 {
+		fst.Cores = 1
+	}
+	if size >= 0 && size < 20480 { // No multicore for silly small files, size -1 is unknown.
 		fst.Cores = 1
 	}
 
 	fst.Row = row
+	fst.startLineage()
+	ranges, err := fst.keyRanges()
+	if nil != err {
+		return err
+	}
+	fst.rowHashRanges = ranges
 	fst.mem = memory.NewGoAllocator()
 	fst.Schema = createSchemaFromFixedRow(*fst)
 
 	fst.wg = &sync.WaitGroup{}
 
-	err := ParalizeChunks(fst, reader, size)
-	if nil != err {
-		return err
-	}
-
 	return nil
 }
 
@@ -284,11 +450,14 @@ func createSchemaFromFixedRow(fst FixedSizeTable) []arrow.Schema {
 			fields[index] = element.DestinField
 		}
 		pos += len
+		fields = append(fields, fst.Lineage.fields()...)
+		fields = append(fields, fst.RowHash.fields()...)
 		res[i] = *arrow.NewSchema(fields, nil)
 	}
 	return res
 }
 
+// FindLastNLCR gives the position just after the last CRLF, 0 when there is none and -1 for an empty buffer.
 func FindLastNLCR(bytes []byte) int {
 	p2 := len(bytes)
 	if 0 == p2 {
@@ -305,15 +474,16 @@ func FindLastNLCR(bytes []byte) int {
 	return 0
 }
 
+// FindLastNL_NO_CR gives the position just after the last LF, 0 when there is none and -1 for an empty buffer. A
+// CRLF ends with LF, so it cuts CRLF lines as well.
 func FindLastNL_NO_CR(bytes []byte) int {
 	p2 := len(bytes)
 	if 0 == p2 {
-		fmt.Println("FindLastNL_NO_CR got empty byte buffer!!!")
 		return -1
 	}
 
 	for p2 > 0 {
-		if p2 < len(bytes) && bytes[p2] == 0x0a {
+		if bytes[p2-1] == 0x0a {
 			return p2
 		}
 		p2--
@@ -328,8 +498,27 @@ type ColumnBuilder interface {
 	Nullify()
 }
 
+// RawColumnBuilder is implemented by builders that want the field bytes as read from the file, before
+// SourceEncoding decoding. ConsumeLine then calls ParseRaw instead of ParseValue.
+type RawColumnBuilder interface {
+	ParseRaw(raw []byte) bool
+}
+
+// ErrColumnBuilder is implemented by builders that can reject a value, Err gives the first rejection. It is
+// checked after every line, so the ChunkError points at the line.
+type ErrColumnBuilder interface {
+	Err() error
+}
+
+// BytesColumnBuilder is implemented by builders that parse the decoded field bytes without going through a string.
+// The slice is only valid during the call.
+type BytesColumnBuilder interface {
+	ParseBytes(b []byte) bool
+}
+
 func CreateColumBuilder(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
-	return ColumnBuilders[fixedField.SourceType.ID()](fixedField, builder, columnsize, fieldNr, columnsizeCap)
+	factory, _ := DefaultRegistry.Lookup(fixedField)
+	return factory(fixedField, builder, columnsize, fieldNr, columnsizeCap)
 }
 
 func ReleaseRecordsForSchema(fst *FixedSizeTable, i int) {
@@ -348,11 +537,14 @@ func ReleaseRecordBuilders(fst *FixedSizeTable) {
 	}
 }
 func ParalizeChunks(fst *FixedSizeTable, reader *io.Reader, size int64) error {
+	if nil == fst.ctx {
+		fst.ctx = context.Background()
+	}
+
+	chunkSize := fst.chunkSize(size)
 	fst.Bytes = make([]byte, size)
 	fst.TableChunks = make([]FixedSizeTableChunk, fst.Cores)
 
-	chunkSize := size / int64(fst.Cores)
-
 	goon := true
 	chunkNr := 0
 	p1 := 0
@@ -362,16 +554,19 @@ func ParalizeChunks(fst *FixedSizeTable, reader *io.Reader, size int64) error {
 		var headerChunk, footerChunk bool
 
 		fst.TableChunks[chunkNr] = FixedSizeTableChunk{FixedSizeTable: fst, Chunkr: chunkNr}
-		fst.TableChunks[chunkNr].createColumBuilders()
+		fst.TableChunks[chunkNr].createColumBuilders(int(chunkSize))
 
-		i1 := int(chunkSize) * chunkNr
-		i2 := int(chunkSize) * (chunkNr + 1)
+		i1 := int(fst.chunkStart(chunkNr, chunkSize))
+		i2 := int(fst.chunkStart(chunkNr+1, chunkSize))
 		if chunkNr == (fst.Cores - 1) {
 			i2 = len(fst.Bytes)
 		}
 		buf := fst.Bytes[i1:i2]
 		startReadChunk := time.Now()
-		nread, err := io.ReadFull(*reader, buf)
+		nread, err := readFull(fst.ctx, *reader, buf)
+		if nil != fst.ctx.Err() {
+			return fst.abandon(chunkNr + 1)
+		}
 		if nil != err {
 			fst.Bytes = nil
 			return err
@@ -385,13 +580,22 @@ func ParalizeChunks(fst *FixedSizeTable, reader *io.Reader, size int64) error {
 		}
 
 		goon = i2 < len(fst.Bytes)
-		i_last_nl := fst.FindLastNL(buf)
+		var i_last_nl int
+		if 0 == chunkNr {
+			i_last_nl = fst.findFirstChunkEnd(buf)
+		} else {
+			i_last_nl = fst.FindLastNL(buf)
+		}
 		if i_last_nl == -1 {
 			fst.Bytes = nil
 			return errors.New("No line found..check data and config")
 		}
 		p2 = i1 + i_last_nl
+		if !goon { // The last line may have no newline, the footer usually has none
+			p2 = i1 + nread
+		}
 		fst.TableChunks[chunkNr].Bytes = fst.Bytes[p1:p2]
+		fst.TableChunks[chunkNr].offset = int64(p1)
 		p1 = p2
 
 		if 0 == chunkNr && fst.HasHeader {
@@ -410,108 +614,438 @@ func ParalizeChunks(fst *FixedSizeTable, reader *io.Reader, size int64) error {
 	fst.wg.Wait()
 	fst.Bytes = nil
 
-	for chunkNrIndex := 0; chunkNrIndex < chunkNr; chunkNrIndex++ {
-		if fst.TableChunks[chunkNrIndex].LinesParsed < 0 {
-			return errors.New("failed process at least one chunk")
-		}
+	err := fst.collectChunks(chunkNr)
+	if nil != err {
+		return err
+	}
+
+	// Finalize the SHA checksum
+	if fst.CalcHash {
+		fst.Hash = sha.Sum(nil)
+	}
+	return nil
+}
+
+// collectChunks gathers the errors, records and statistics of the first chunks after the workers are done.
+func (fst *FixedSizeTable) collectChunks(chunks int) error {
+	if nil != fst.ctx.Err() {
+		return fst.abandon(chunks)
 	}
 
+	var chunkErrors ChunkErrors
+	for chunkNrIndex := 0; chunkNrIndex < chunks; chunkNrIndex++ {
+		chunkErrors = append(chunkErrors, fst.TableChunks[chunkNrIndex].Err...)
+	}
+	if 0 < len(chunkErrors) {
+		fst.moveErrorLines(chunks)
+		fst.releaseChunks(chunks)
+		return chunkErrors
+	}
+
+	fst.moveLineNumbers(chunks)
+
 	//	var r []array.Record=make([]array.Record, len(fst.TableChunks))
 	fst.Records = make([][]arrow.Record, len(fst.TableColAmount))
 
-	for i := 0; i < len(fst.TableColAmount); i++ {
-		fst.Records[i] = make([]arrow.Record, chunkNr)
+	tables := len(fst.TableColAmount)
+	for i := 0; i < tables; i++ {
+		fst.Records[i] = make([]arrow.Record, 0, chunks)
 		for j := 0; j < len(fst.TableChunks); j++ {
-			fst.Records[i][j] = fst.TableChunks[j].Record[i] // TODO
+			for k := i; k < len(fst.TableChunks[j].Record); k += tables {
+				fst.Records[i] = append(fst.Records[i], fst.TableChunks[j].Record[k])
+			}
 		}
 	}
 
+	err := unifyDictionaries(fst)
+	if nil != err {
+		return err
+	}
+
 	// Sum up some statitics
 	for _, tableChunk := range fst.TableChunks {
 		fst.DurationToArrow += tableChunk.DurationToArrow
 		fst.DurationReadChunk += tableChunk.DurationReadChunk
 		fst.DurationToExport += tableChunk.DurationToExport
 		fst.LinesParsed += tableChunk.LinesParsed
+		fst.ShortLines += tableChunk.ShortLines
+		fst.LongLines += tableChunk.LongLines
+		fst.RejectedLines += tableChunk.RejectedLines
+		fst.SkippedLines += tableChunk.SkippedLines
 	}
 
-	// Finalize the SHA checksum
-	if fst.CalcHash {
-		fst.Hash = sha.Sum(nil)
+	fst.addLayoutMetadata()
+	fst.columnChecksums()
+	return fst.reconcile()
+}
+
+// Workers look for cancellation every cancelCheckLines lines
+const cancelCheckLines = 1024
+
+// readFull is io.ReadFull looking for cancellation between the reads.
+func readFull(ctx context.Context, reader io.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) && nil == ctx.Err() {
+		nread, err := reader.Read(buf[n:])
+		n += nread
+		if io.EOF == err {
+			if n == len(buf) {
+				return n, nil
+			}
+			if n > 0 {
+				return n, io.ErrUnexpectedEOF
+			}
+		}
+		if nil != err {
+			return n, err
+		}
 	}
-	return nil
+	return n, nil
 }
 
-func (fstc *FixedSizeTableChunk) process(lfHeader bool, lfFooter bool) {
-	startToArrow := time.Now()
-	defer fstc.FixedSizeTable.wg.Done()
+// abandon waits for the started chunks, releases their builders and records and returns ctx.Err().
+func (fst *FixedSizeTable) abandon(chunks int) error {
+	fst.wg.Wait()
+	fst.Bytes = nil
+	fst.releaseChunks(chunks)
 
-	var bbb []byte
+	return fst.ctx.Err()
+}
 
-	if lfFooter {
-		p := fstc.FixedSizeTable.FindLastNL(fstc.Bytes)
-		if p < 0 {
-			fstc.LinesParsed = -1
+func (fst *FixedSizeTable) releaseChunks(chunks int) {
+	for k := 0; k < chunks; k++ {
+		for _, record := range fst.TableChunks[k].Record {
+			record.Release()
+		}
+		for _, rb := range fst.TableChunks[k].RecordBuilder {
+			rb.Release()
 		}
+	}
+	fst.TableChunks = nil
+}
 
-		fstc.FixedSizeTable.Footer = string(fstc.Bytes[p:])
-		bbb = fstc.Bytes[0:p]
-	} else {
-		bbb = fstc.Bytes
+func (fstc *FixedSizeTableChunk) process(lfHeader bool, lfFooter bool) {
+	defer fstc.FixedSizeTable.wg.Done()
+	if pool := fstc.FixedSizeTable.pool; nil != pool {
+		pool <- struct{}{}
+		defer func() { <-pool }()
 	}
+	startToArrow := time.Now()
 
-	re := bytes.NewReader(bbb)
-	var scanner bufio.Scanner
+	lineCnt := 0
+	defer func() {
+		if r := recover(); nil != r {
+			fstc.fail(lineCnt, &PanicError{Value: r})
+		}
+	}()
+
+	// The raw bytes are scanned, decoding is done per line or per field so they stay available for RawColumnBuilders
+	fstc.decoder = fstc.FixedSizeTable.newDecoder()
+	fstc.byteTable = fstc.FixedSizeTable.byteTable
+
+	// Where the lines start, for the lineage, after a byte order mark
+	var lineStart, nextLine int
+	bbb := fstc.Bytes
+	if bom := fstc.FixedSizeTable.bomLength; 0 == fstc.offset && 0 < bom && bom <= len(bbb) {
+		bbb = bbb[bom:]
+		nextLine = bom
+	}
+	if lfFooter {
+		var err error
+		bbb, err = fstc.cutFooter(bbb)
+		if nil != err {
+			fstc.fail(0, err)
+			return
+		}
+	}
 
-	switch strings.ToLower(fstc.FixedSizeTable.SourceEncoding) {
-	case "iso8859-1":
-		scanner = *bufio.NewScanner(transform.NewReader(re, charmap.ISO8859_1.NewDecoder()))
+	scanner := bufio.NewScanner(bytes.NewReader(bbb))
+	maxLine := 2 * fstc.FixedSizeTable.Row.CalRowLength()
+	if maxLine > bufio.MaxScanTokenSize {
+		scanner.Buffer(nil, maxLine)
+	}
 
-	case "utf-8":
-		scanner = *bufio.NewScanner(re)
-	default:
-		scanner = *bufio.NewScanner(re)
+	scanner.Split(fstc.FixedSizeTable.scanLines)
+	if nil != fstc.lineage {
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			advance, token, err := fstc.FixedSizeTable.scanLines(data, atEOF)
+			if nil != token {
+				lineStart, nextLine = nextLine, nextLine+advance
+			}
+			return advance, token, err
+		})
 	}
-	lineCnt := 0
+
+	consumeBytes := fstc.FixedSizeTable.ConsumeBytesFunc
+	maxRecordRows := fstc.FixedSizeTable.MaxRecordRows
+	batchRows := 0
+	done := fstc.FixedSizeTable.ctx.Done()
+	skipLines := fstc.FixedSizeTable.SkipBlankLines || "" != fstc.FixedSizeTable.CommentPrefix
+	inHeader := lfHeader
+	headerLines, skipped := 0, 0
 	for scanner.Scan() {
-		line := scanner.Text()
+		fstc.RawLine = scanner.Bytes()
 		lineCnt++
 
-		if lfHeader && 1 == lineCnt {
-			fstc.FixedSizeTable.Header = line
+		if 0 == lineCnt%cancelCheckLines {
+			select {
+			case <-done:
+				fstc.LinesParsed = -1
+				return
+			default:
+			}
+		}
+
+		if skipLines && fstc.FixedSizeTable.skipLine(fstc.lineText(fstc.RawLine)) {
+			skipped++
 			continue
 		}
 
-		fstc.FixedSizeTable.ConsumeLineFunc(line, fstc)
+		if inHeader {
+			if fstc.FixedSizeTable.isHeaderLine(fstc.lineText(fstc.RawLine), headerLines) {
+				if err := fstc.addHeader(fstc.RawLine, headerLines); nil != err {
+					fstc.fail(lineCnt, err)
+					return
+				}
+				headerLines++
+				continue
+			}
+			inHeader = false
+		}
+
+		raw := fstc.RawLine
+		line, ok, err := fstc.fitLine(raw)
+		if nil != err {
+			fstc.fail(lineCnt, err)
+			return
+		}
+		if !ok {
+			continue
+		}
+		fstc.RawLine = line
+
+		if nil != consumeBytes {
+			consumeBytes(fstc.RawLine, fstc)
+		} else {
+			fstc.FixedSizeTable.ConsumeLineFunc(fstc.decodeLine(fstc.RawLine), fstc)
+		}
+		if 0 < len(fstc.errBuilders) {
+			if err := fstc.builderErr(); nil != err {
+				fstc.fail(lineCnt, err)
+				return
+			}
+		}
+		if nil != fstc.lineage {
+			fstc.appendLineage(lineCnt, lineStart)
+		}
+		if nil != fstc.rowHash {
+			fstc.appendRowHash(raw)
+		}
 
+		batchRows++
+		if batchRows == maxRecordRows {
+			fstc.newRecords()
+			fstc.reserve()
+			batchRows = 0
+		}
 	}
-	// TODO check scanner.err()
-	for ci, _ := range fstc.FixedSizeTable.Row.FixedField {
-		fstc.ColumnBuilders[ci].FinishColumn()
+	if err := scanner.Err(); nil != err {
+		fstc.fail(lineCnt+1, err)
+		return
 	}
+	fstc.lines = lineCnt
 
-	if lfHeader {
-		lineCnt--
+	var columnErrors []error
+	for ci, ff := range fstc.FixedSizeTable.Row.FixedField {
+		if !fstc.ColumnBuilders[ci].FinishColumn() {
+			columnErrors = append(columnErrors, columnError(fstc.ColumnBuilders[ci], ff.DestinField.Name))
+		}
 	}
-
-	if lfFooter {
-		lineCnt--
+	if 0 < len(columnErrors) {
+		for _, err := range columnErrors {
+			fstc.fail(0, err)
+		}
+		return
 	}
 
-	for _, rb := range fstc.RecordBuilder {
-		fstc.Record = append(fstc.Record, rb.NewRecord())
+	// The footer is cut off before scanning
+	lineCnt -= headerLines + skipped
+	fstc.SkippedLines += skipped
+
+	if batchRows > 0 || 0 == len(fstc.Record) {
+		fstc.newRecords()
 	}
 
 	fstc.LinesParsed = lineCnt
 	fstc.DurationToArrow = time.Since(startToArrow)
 }
 
+// builderErr gives the first value rejected by a builder.
+func (fstc *FixedSizeTableChunk) builderErr() error {
+	for _, eb := range fstc.errBuilders {
+		if err := eb.Err(); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnError tells why FinishColumn failed, with the error of the builder when it has one.
+func columnError(cb ColumnBuilder, name string) error {
+	if eb, ok := cb.(ErrColumnBuilder); ok && nil != eb.Err() {
+		return fmt.Errorf("column %s failed: %w", name, eb.Err())
+	}
+	return fmt.Errorf("column %s failed", name)
+}
+
+// fail records an error of the chunk at line, counting from 1 within the chunk, 0 when no line is to blame.
+func (fstc *FixedSizeTableChunk) fail(line int, err error) {
+	fstc.LinesParsed = -1
+	fstc.Err = append(fstc.Err, &ChunkError{Chunk: fstc.Chunkr, Line: line, Err: err})
+}
+
+// moveErrorLines makes the Line of the chunk errors count from the start of the input instead of the chunk.
+func (fst *FixedSizeTable) moveErrorLines(chunks int) {
+	lines := 0
+	for k := 0; k < chunks; k++ {
+		fstc := &fst.TableChunks[k]
+		for _, err := range fstc.Err {
+			if 0 < err.Line {
+				err.Line += lines
+			}
+		}
+		if -1 == fstc.LinesParsed { // It stopped at the failure, the lines after are counted here
+			lines += fstc.countLines()
+		} else {
+			lines += fstc.lines
+		}
+	}
+}
+
+// countLines counts the lines of the chunk the way process scans them.
+func (fstc *FixedSizeTableChunk) countLines() int {
+	fst := fstc.FixedSizeTable
+	data := fstc.Bytes
+	if bom := fst.bomLength; 0 == fstc.offset && 0 < bom && bom <= len(data) {
+		data = data[bom:]
+	}
+	lines := 0
+	for 0 < len(data) {
+		advance, token, err := fst.scanLines(data, true)
+		if nil != err || 0 == advance {
+			break
+		}
+		if nil != token {
+			lines++
+		}
+		data = data[advance:]
+	}
+	return lines
+}
+
+// newDecoder gives a decoder for the SourceEncoding, nil when the bytes need no decoding or the byteTable
+// decodes them.
+func (fst *FixedSizeTable) newDecoder() *encoding.Decoder {
+	if nil != fst.encoding && nil == fst.byteTable {
+		return fst.encoding.NewDecoder()
+	}
+	return nil
+}
+
+func (fstc *FixedSizeTableChunk) decodeLine(raw []byte) string {
+	if t := fstc.byteTable; nil != t {
+		return string(t.decode(nil, raw))
+	}
+	if nil == fstc.decoder {
+		return string(raw)
+	}
+
+	decoded, _ := fstc.decoder.Bytes(raw)
+	return string(decoded)
+}
+
+// lineText returns the decoded line for matching, in a buffer reused by the next call.
+func (fstc *FixedSizeTableChunk) lineText(raw []byte) []byte {
+	if t := fstc.byteTable; nil != t && !t.plain(raw) {
+		fstc.textBuf = t.decode(fstc.textBuf[:0], raw)
+		return fstc.textBuf
+	}
+	if nil == fstc.decoder || nil != fstc.byteTable {
+		return raw
+	}
+
+	fstc.textBuf, _, _ = transform.Append(fstc.decoder, fstc.textBuf[:0], raw)
+	return fstc.textBuf
+}
+
+// decodeField returns the decoded field in a buffer reused by the next call.
+func (fstc *FixedSizeTableChunk) decodeField(raw []byte) []byte {
+	if t := fstc.byteTable; nil != t && !t.plain(raw) {
+		fstc.fieldBuf = t.decode(fstc.fieldBuf[:0], raw)
+		return fstc.fieldBuf
+	}
+	if nil == fstc.decoder || nil != fstc.byteTable {
+		return raw
+	}
+
+	fstc.fieldBuf, _, _ = transform.Append(fstc.decoder, fstc.fieldBuf[:0], raw)
+	return fstc.fieldBuf
+}
+
+// ConsumeBytes is the default line consumer. Fields are cut from the raw line and decoded one by one, so a
+// SourceEncoding decoding into multi byte UTF-8 never shifts the field positions.
+// TODO fix for utf8 sources, Len is counted in bytes
+func ConsumeBytes(line []byte, fstc *FixedSizeTableChunk) {
+	// Cap it so a short line can never be sliced into the scanner buffer beyond it
+	line = line[:len(line):len(line)]
+
+	var columnPos int
+	for ci := range fstc.FixedSizeTable.Row.FixedField {
+		end := fstc.fieldEnd(ci, columnPos, len(line))
+		if end > len(line) {
+			fstc.ColumnBuilders[ci].Nullify()
+			columnPos = end
+			continue
+		}
+		field := line[columnPos:end]
+		columnPos = end
+
+		if nil != fstc.rawBuilders[ci] {
+			fstc.rawBuilders[ci].ParseRaw(field)
+			continue
+		}
+
+		field = fstc.decodeField(field)
+		if nil != fstc.bytesBuilders[ci] {
+			fstc.bytesBuilders[ci].ParseBytes(field)
+		} else {
+			fstc.ColumnBuilders[ci].ParseValue(string(field))
+		}
+	}
+}
+
 // TODO fix for utf8 !!! this is only for Ascii/8851-9 one byte coded glyphs
 func ConsumeLine(line string, fstc *FixedSizeTableChunk) {
 	var columnPos int
-	for ci, cc := range fstc.FixedSizeTable.Row.FixedField {
-		columString := line[columnPos : columnPos+cc.Len]
-		fstc.ColumnBuilders[ci].ParseValue(columString)
-		columnPos += cc.Len
+	for ci := range fstc.FixedSizeTable.Row.FixedField {
+		raw, isRaw := fstc.ColumnBuilders[ci].(RawColumnBuilder)
+		isRaw = isRaw && nil != fstc.RawLine
+
+		length := len(line)
+		if isRaw {
+			length = len(fstc.RawLine)
+		}
+		end := fstc.fieldEnd(ci, columnPos, length)
+
+		if end > length {
+			fstc.ColumnBuilders[ci].Nullify()
+		} else if isRaw {
+			raw.ParseRaw(fstc.RawLine[columnPos:end])
+		} else {
+			columString := line[columnPos:end]
+			fstc.ColumnBuilders[ci].ParseValue(columString)
+		}
+		columnPos = end
 	}
 }
 
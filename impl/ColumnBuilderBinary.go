@@ -0,0 +1,155 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+type BinaryFormat int
+
+const (
+	// The field bytes are kept exactly as read, before any SourceEncoding decoding.
+	BinaryRaw BinaryFormat = iota
+	// The field holds hex text, ie "0A1B".
+	BinaryHex
+	// The field holds standard base64 text, padded or not.
+	BinaryBase64
+)
+
+// ColumnBuilderBinary builds Binary columns and, through ColumnBuilderLargeBinary, LargeBinary columns.
+// Hex and base64 text is expected in an ASCII compatible encoding.
+type ColumnBuilderBinary struct {
+	fixedField    *FixedField
+	recordBuilder *array.RecordBuilder
+	fieldnr       int
+	builder       *array.BinaryBuilder
+	buf           []byte
+}
+
+type ColumnBuilderLargeBinary struct {
+	ColumnBuilderBinary
+}
+
+func (c *ColumnBuilderBinary) ParseRaw(raw []byte) bool {
+	if BinaryRaw == c.fixedField.BinaryFormat {
+		c.builder.Append(raw)
+		return true
+	}
+
+	var ok bool
+	c.buf, ok = appendBinary(c.buf[:0], raw, c.fixedField.BinaryFormat)
+	if !ok {
+		c.Nullify()
+		return false
+	}
+
+	c.builder.Append(c.buf)
+	return true
+}
+
+func (c *ColumnBuilderBinary) ParseValue(name string) bool {
+	return c.ParseRaw([]byte(name))
+}
+
+func (c *ColumnBuilderBinary) FinishColumn() bool {
+	return true
+}
+
+func (c *ColumnBuilderBinary) Nullify() {
+	c.builder.AppendNull()
+}
+
+type ColumnBuilderFixedSizeBinary struct {
+	fixedField    *FixedField
+	recordBuilder *array.RecordBuilder
+	fieldnr       int
+	builder       *array.FixedSizeBinaryBuilder
+	byteWidth     int
+	buf           []byte
+}
+
+func (c *ColumnBuilderFixedSizeBinary) ParseRaw(raw []byte) bool {
+	var ok bool
+	c.buf, ok = appendBinary(c.buf[:0], raw, c.fixedField.BinaryFormat)
+	if !ok || len(c.buf) != c.byteWidth {
+		c.Nullify()
+		return false
+	}
+
+	c.builder.Append(c.buf)
+	return true
+}
+
+func (c *ColumnBuilderFixedSizeBinary) ParseValue(name string) bool {
+	return c.ParseRaw([]byte(name))
+}
+
+func (c *ColumnBuilderFixedSizeBinary) FinishColumn() bool {
+	return true
+}
+
+func (c *ColumnBuilderFixedSizeBinary) Nullify() {
+	c.builder.AppendNull()
+}
+
+func fixedSizeBinaryWidth(fixedField *FixedField) int {
+	return fixedField.DestinField.Type.(*arrow.FixedSizeBinaryType).ByteWidth
+}
+
+// appendBinary decodes a field according to format and appends the bytes to dst. Blank hex and base64 fields are not ok.
+func appendBinary(dst []byte, raw []byte, format BinaryFormat) ([]byte, bool) {
+	switch format {
+	case BinaryHex:
+		text := bytes.TrimSpace(raw)
+		if 0 == len(text) || 0 != len(text)%2 {
+			return dst, false
+		}
+		n := len(dst)
+		dst = append(dst, make([]byte, hex.DecodedLen(len(text)))...)
+		if _, err := hex.Decode(dst[n:], text); nil != err {
+			return dst[:n], false
+		}
+		return dst, true
+
+	case BinaryBase64:
+		text := bytes.TrimSpace(raw)
+		if 0 == len(text) {
+			return dst, false
+		}
+		enc := base64.StdEncoding
+		if 0 != len(text)%4 {
+			enc = base64.RawStdEncoding
+		}
+		n := len(dst)
+		dst = append(dst, make([]byte, enc.DecodedLen(len(text)))...)
+		m, err := enc.Decode(dst[n:], text)
+		if nil != err {
+			return dst[:n], false
+		}
+		return dst[:n+m], true
+	}
+
+	return append(dst, raw...), true
+}
@@ -20,19 +20,79 @@
 package impl
 
 import (
+	"fmt"
 	"github.com/apache/arrow/go/v13/arrow/array"
+	"strings"
 )
 
+// BooleanFormat holds the true/false tokens of a boolean field, ie "1"/"0", "T"/"F", "JA"/"NEJ" or "X"/" ".
+// Tokens are matched with surrounding blanks removed. Unknown tokens become null, or with UnknownAsError
+// fail the conversion.
+type BooleanFormat struct {
+	TrueValues     []string
+	FalseValues    []string
+	CaseSensitive  bool
+	UnknownAsError bool
+}
+
 type ColumnBuilderBoolean struct {
 	fixedField    *FixedField
 	recordBuilder *array.RecordBuilder
 	fieldnr       int
-	values        []bool
-	valid         []bool
+	tokens        map[string]bool
+	err           error
+	builder       *array.BooleanBuilder
+}
+
+func booleanTokens(format *BooleanFormat) map[string]bool {
+	if nil == format {
+		return nil
+	}
+
+	tokens := make(map[string]bool, len(format.TrueValues)+len(format.FalseValues))
+	for _, t := range format.FalseValues {
+		tokens[format.normalize(t)] = false
+	}
+	for _, t := range format.TrueValues {
+		tokens[format.normalize(t)] = true
+	}
+	return tokens
+}
+
+func (f *BooleanFormat) normalize(token string) string {
+	token = strings.TrimSpace(token)
+	if !f.CaseSensitive {
+		token = strings.ToLower(token)
+	}
+	return token
 }
 
-// make configurable
 func (c *ColumnBuilderBoolean) ParseValue(name string) bool {
+	if nil == c.tokens {
+		return c.parseDefault(name)
+	}
+
+	format := c.fixedField.Boolean
+	ourBool, ok := c.tokens[format.normalize(name)]
+	if !ok {
+		if format.UnknownAsError && nil == c.err {
+			c.err = fmt.Errorf("field %s: unknown boolean token %q", c.fixedField.DestinField.Name, name)
+		}
+		c.Nullify()
+		return false
+	}
+
+	c.builder.Append(ourBool)
+	return true
+}
+
+// Without a BooleanFormat only the first byte is looked at, J/j/Y/y is true and N/n false.
+func (c *ColumnBuilderBoolean) parseDefault(name string) bool {
+	if 0 == len(name) {
+		c.Nullify()
+		return false
+	}
+
 	boolChar := name[0]
 	var ourBool bool
 
@@ -60,22 +120,28 @@ func (c *ColumnBuilderBoolean) ParseValue(name string) bool {
 		ourBool = false
 		break
 	default:
-		c.values = append(c.values, false)
-		c.valid = append(c.valid, false)
+		c.Nullify()
 		return false
 	}
 
-	c.values = append(c.values, ourBool)
-	c.valid = append(c.valid, true)
+	c.builder.Append(ourBool)
 	return true
 }
 
+// The parse functions never keep the string, so the bytes are parsed without a copy.
+func (c *ColumnBuilderBoolean) ParseBytes(b []byte) bool {
+	return c.ParseValue(bytesToString(b))
+}
+
 func (c *ColumnBuilderBoolean) FinishColumn() bool {
-	c.recordBuilder.Field(c.fieldnr).(*array.BooleanBuilder).AppendValues(c.values, c.valid)
-	return true
+	return nil == c.err
+}
+
+// Err gives the first unknown token with UnknownAsError.
+func (c *ColumnBuilderBoolean) Err() error {
+	return c.err
 }
 
 func (c *ColumnBuilderBoolean) Nullify() {
-	c.values = append(c.values, false)
-	c.valid = append(c.valid, false)
+	c.builder.AppendNull()
 }
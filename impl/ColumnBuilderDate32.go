@@ -22,14 +22,14 @@ package impl
 import (
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/apache/arrow/go/v13/arrow/array"
+	"time"
 )
 
 type ColumnBuilderDate32 struct {
 	fixedField    *FixedField
 	recordBuilder *array.RecordBuilder
 	fieldnr       int
-	values        []arrow.Date32
-	valid         []bool
+	builder       *array.Date32Builder
 }
 
 func (c *ColumnBuilderDate32) ParseValue(name string) bool {
@@ -40,19 +40,27 @@ func (c *ColumnBuilderDate32) ParseValue(name string) bool {
 		return false
 	}
 
-	c.values = append(c.values, arrow.Date32(t))
-	c.valid = append(c.valid, true)
+	c.builder.Append(arrow.Date32FromTime(time.Unix(t, 0).UTC()))
 
 	return true
 }
 
-func (c *ColumnBuilderDate32) FinishColumn() bool {
-	c.recordBuilder.Field(c.fieldnr).(*array.Date32Builder).AppendValues(c.values, c.valid)
+func (c *ColumnBuilderDate32) ParseBytes(b []byte) bool {
+	days, ok := parseDateT1Days(b, 0)
+	if !ok {
+		c.Nullify()
+		return false
+	}
+
+	c.builder.Append(arrow.Date32(days))
 
 	return true
 }
 
+func (c *ColumnBuilderDate32) FinishColumn() bool {
+	return true
+}
+
 func (c *ColumnBuilderDate32) Nullify() {
-	c.values = append(c.values, 0)
-	c.valid = append(c.valid, false)
+	c.builder.AppendNull()
 }
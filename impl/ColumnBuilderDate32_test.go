@@ -0,0 +1,65 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+func TestDate32(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 19, DestinField: arrow.Field{Name: "date", Type: arrow.FixedWidthTypes.Date32, Nullable: true}, SourceType: arrow.FixedWidthTypes.Date32},
+	}}
+	data := []byte("2024-01-31-00.00.00\n2024-01-31-23.59.59\n1969-12-31-12.00.00\n2024-01-3x-00.00.00\n")
+	want := []string{"2024-01-31", "2024-01-31", "1969-12-31", ""}
+
+	for _, consumeLine := range []bool{false, true} {
+		fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF}
+		if consumeLine {
+			fst.ConsumeLineFunc = ConsumeLine
+		}
+		var reader io.Reader = bytes.NewReader(data)
+		if err := CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(data))); nil != err {
+			t.Fatal(err)
+		}
+
+		dates := fst.Records[0][0].Column(0).(*array.Date32)
+		for i, w := range want {
+			if "" == w {
+				if dates.IsValid(i) {
+					t.Errorf("row %d is %s, want null", i, dates.Value(i).FormattedString())
+				}
+				continue
+			}
+			if got := dates.Value(i).ToTime().Format("2006-01-02"); got != w {
+				t.Errorf("ConsumeLine %v row %d is %s, want %s", consumeLine, i, got, w)
+			}
+		}
+		if got := dates.Value(0); got != arrow.Date32FromTime(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("2024-01-31 is day %d", got)
+		}
+		fst.releaseRecords()
+	}
+}
@@ -28,8 +28,7 @@ type ColumnBuilderDate64 struct {
 	fixedField    *FixedField
 	recordBuilder *array.RecordBuilder
 	fieldnr       int
-	values        []arrow.Date64
-	valid         []bool
+	builder       *array.Date64Builder
 }
 
 func (c *ColumnBuilderDate64) ParseValue(name string) bool {
@@ -39,18 +38,28 @@ func (c *ColumnBuilderDate64) ParseValue(name string) bool {
 		c.Nullify()
 		return false
 	}
-	c.values = append(c.values, arrow.Date64FromTime(ti))
-	c.valid = append(c.valid, true)
+	c.builder.Append(arrow.Date64FromTime(ti))
+
+	return true
+}
+
+func (c *ColumnBuilderDate64) ParseBytes(b []byte) bool {
+	days, ok := parseDateT1Days(b, 6)
+	if !ok {
+		c.Nullify()
+		return false
+	}
+
+	// Date64 holds the milliseconds of midnight
+	c.builder.Append(arrow.Date64(days * 86400000))
 
 	return true
 }
 
 func (c *ColumnBuilderDate64) FinishColumn() bool {
-	c.recordBuilder.Field(c.fieldnr).(*array.Date64Builder).AppendValues(c.values, c.valid)
 	return true
 }
 
 func (c *ColumnBuilderDate64) Nullify() {
-	c.values = append(c.values, 0)
-	c.valid = append(c.valid, false)
+	c.builder.AppendNull()
 }
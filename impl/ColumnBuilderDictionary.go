@@ -0,0 +1,91 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// ColumnBuilderDictionary builds dictionary encoded string columns, use an arrow.DictionaryType with a String
+// value type as both SourceType and destination type. Values go straight into the memo table of the arrow
+// builder so low cardinality columns never materialize as a []string.
+type ColumnBuilderDictionary struct {
+	fixedField    *FixedField
+	recordBuilder *array.RecordBuilder
+	fieldnr       int
+	builder       *array.BinaryDictionaryBuilder
+}
+
+func (c *ColumnBuilderDictionary) ParseValue(name string) bool {
+	if nil != c.builder.AppendString(name) {
+		c.Nullify()
+		return false
+	}
+	return true
+}
+
+func (c *ColumnBuilderDictionary) ParseBytes(b []byte) bool {
+	if nil != c.builder.Append(b) {
+		c.Nullify()
+		return false
+	}
+	return true
+}
+
+func (c *ColumnBuilderDictionary) FinishColumn() bool {
+	return true
+}
+
+func (c *ColumnBuilderDictionary) Nullify() {
+	c.builder.AppendNull()
+}
+
+// Every chunk builds its own dictionary, unify them per column so all records of a table share one.
+func unifyDictionaries(fst *FixedSizeTable) error {
+	for i, schema := range fst.Schema {
+		for ci, field := range schema.Fields() {
+			if arrow.DICTIONARY != field.Type.ID() || len(fst.Records[i]) < 2 {
+				continue
+			}
+
+			columns := make([]arrow.Array, len(fst.Records[i]))
+			for j, record := range fst.Records[i] {
+				columns[j] = record.Column(ci)
+			}
+
+			chunked := arrow.NewChunked(field.Type, columns)
+			unified, err := array.UnifyChunkedDicts(fst.mem, chunked)
+			chunked.Release()
+			if nil != err {
+				return err
+			}
+
+			for j, record := range fst.Records[i] {
+				cols := append([]arrow.Array{}, record.Columns()...)
+				cols[ci] = unified.Chunk(j)
+				fst.Records[i][j] = array.NewRecord(record.Schema(), cols, record.NumRows())
+				record.Release()
+			}
+			unified.Release()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/decimal128"
+	"github.com/apache/arrow/go/v13/arrow/decimal256"
+	"github.com/apache/arrow/go/v13/arrow/float16"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumericParser parses the bytes of one field into a value of an arrow primitive type, false means null.
+// The bytes belong to the scanned line and must not be kept.
+type NumericParser[T any] func(fixedField *FixedField, b []byte) (T, bool)
+
+type numericBuilder[T any] interface {
+	Append(v T)
+	AppendNull()
+}
+
+// ColumnBuilderNumeric is one ColumnBuilder for all the primitive types, the parse function decides the dialect.
+type ColumnBuilderNumeric[T any] struct {
+	fixedField    *FixedField
+	recordBuilder *array.RecordBuilder
+	fieldnr       int
+	builder       numericBuilder[T]
+	parse         NumericParser[T]
+}
+
+type ColumnBuilderInt8 = ColumnBuilderNumeric[int8]
+type ColumnBuilderInt16 = ColumnBuilderNumeric[int16]
+type ColumnBuilderInt32 = ColumnBuilderNumeric[int32]
+type ColumnBuilderInt64 = ColumnBuilderNumeric[int64]
+type ColumnBuilderUint8 = ColumnBuilderNumeric[uint8]
+type ColumnBuilderUint16 = ColumnBuilderNumeric[uint16]
+type ColumnBuilderUint32 = ColumnBuilderNumeric[uint32]
+type ColumnBuilderUint64 = ColumnBuilderNumeric[uint64]
+type ColumnBuilderFloat32 = ColumnBuilderNumeric[float32]
+type ColumnBuilderFloat64 = ColumnBuilderNumeric[float64]
+type ColumnBuilderDecimal128 = ColumnBuilderNumeric[decimal128.Num]
+
+func (c *ColumnBuilderNumeric[T]) ParseValue(name string) bool {
+	return c.ParseBytes(stringToBytes(name))
+}
+
+func (c *ColumnBuilderNumeric[T]) ParseBytes(b []byte) bool {
+	v, ok := c.parse(c.fixedField, b)
+	if !ok {
+		c.Nullify()
+		return false
+	}
+
+	c.builder.Append(v)
+
+	return true
+}
+
+func (c *ColumnBuilderNumeric[T]) FinishColumn() bool {
+	return true
+}
+
+func (c *ColumnBuilderNumeric[T]) Nullify() {
+	c.builder.AppendNull()
+}
+
+// NumericColumnBuilder makes a ColumnBuilderFactory for a type whose arrow builder has Append(T),
+// usable in a Registry or FixedField.Builder to swap the parse dialect of a type.
+func NumericColumnBuilder[T any](parse NumericParser[T]) ColumnBuilderFactory {
+	return func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		var result ColumnBuilder
+		result = &ColumnBuilderNumeric[T]{fixedField: fixedField, recordBuilder: builder, builder: builder.Field(fieldNr).(numericBuilder[T]), parse: parse, fieldnr: fieldNr}
+		return &result
+	}
+}
+
+// RegisterNumeric registers parse as the builder for source type id in r.
+func RegisterNumeric[T any](r *Registry, id arrow.Type, parse NumericParser[T]) {
+	r.Register(id, NumericColumnBuilder(parse))
+}
+
+func registerNumericBuilders(r *Registry) {
+	RegisterNumeric(r, arrow.INT8, ParseIntField[int8](math.MinInt8, math.MaxInt8))
+	RegisterNumeric(r, arrow.INT16, ParseIntField[int16](math.MinInt16, math.MaxInt16))
+	RegisterNumeric(r, arrow.INT32, ParseIntField[int32](math.MinInt32, math.MaxInt32))
+	RegisterNumeric(r, arrow.INT64, ParseIntField[int64](math.MinInt64, math.MaxInt64))
+	RegisterNumeric(r, arrow.UINT8, ParseUintField[uint8](math.MaxUint8))
+	RegisterNumeric(r, arrow.UINT16, ParseUintField[uint16](math.MaxUint16))
+	RegisterNumeric(r, arrow.UINT32, ParseUintField[uint32](math.MaxUint32))
+	RegisterNumeric(r, arrow.UINT64, ParseUintField[uint64](math.MaxUint64))
+	RegisterNumeric(r, arrow.FLOAT16, ParseFloat16Field)
+	RegisterNumeric(r, arrow.FLOAT32, ParseFloatField[float32](32))
+	RegisterNumeric(r, arrow.FLOAT64, ParseFloatField[float64](64))
+	RegisterNumeric(r, arrow.DECIMAL128, ParseDecimal128Field)
+	RegisterNumeric(r, arrow.DECIMAL256, ParseDecimal256Field)
+	RegisterNumeric(r, arrow.DURATION, ParseDurationField)
+	RegisterNumeric(r, arrow.INTERVAL_MONTHS, ParseMonthIntervalField)
+	RegisterNumeric(r, arrow.INTERVAL_DAY_TIME, ParseDayTimeIntervalField)
+	RegisterNumeric(r, arrow.INTERVAL_MONTH_DAY_NANO, ParseMonthDayNanoIntervalField)
+}
+
+// ParseIntField parses a signed integer, by the NumberLocale of the field if it has one.
+func ParseIntField[T int8 | int16 | int32 | int64](min int64, max int64) NumericParser[T] {
+	return func(fixedField *FixedField, b []byte) (T, bool) {
+		if nil != fixedField.NumberLocale {
+			text, ok := fixedField.integerText(string(b))
+			if !ok {
+				return 0, false
+			}
+			b = stringToBytes(text)
+		}
+
+		i, ok := parseIntBytes(b, min, max)
+		return T(i), ok
+	}
+}
+
+// ParseUintField parses an unsigned integer, by the NumberLocale of the field if it has one.
+func ParseUintField[T uint8 | uint16 | uint32 | uint64](max uint64) NumericParser[T] {
+	return func(fixedField *FixedField, b []byte) (T, bool) {
+		if nil != fixedField.NumberLocale {
+			text, ok := fixedField.integerText(string(b))
+			if !ok {
+				return 0, false
+			}
+			b = stringToBytes(text)
+		}
+
+		u, ok := parseUintBytes(b, max)
+		return T(u), ok
+	}
+}
+
+// ParseFloatField parses a floating point number, a percentage is divided by 100.
+func ParseFloatField[T float32 | float64](bitSize int) NumericParser[T] {
+	return func(fixedField *FixedField, b []byte) (T, bool) {
+		text, percent, ok := fixedField.floatText(bytesToString(b))
+		if !ok {
+			return 0, false
+		}
+
+		f, ok := parseFloatBytes(stringToBytes(text), bitSize)
+		if !ok {
+			return 0, false
+		}
+		if percent {
+			f /= 100
+		}
+
+		return T(f), true
+	}
+}
+
+var parseFloat32Field = ParseFloatField[float32](32)
+
+func ParseFloat16Field(fixedField *FixedField, b []byte) (float16.Num, bool) {
+	f, ok := parseFloat32Field(fixedField, b)
+	return float16.New(f), ok
+}
+
+// decimalScale gives the scale to parse at, a percentage is parsed two decimals further to the right,
+// ie 12.5% with scale 2 is 0.13
+func decimalScale(fixedField *FixedField, b []byte, scale int32) (string, int32, bool) {
+	text, percent, ok := fixedField.floatText(bytesToString(b))
+	if percent {
+		scale -= 2
+	}
+	return strings.TrimSpace(text), scale, ok
+}
+
+func ParseDecimal128Field(fixedField *FixedField, b []byte) (decimal128.Num, bool) {
+	dt := fixedField.DestinField.Type.(*arrow.Decimal128Type)
+	text, scale, ok := decimalScale(fixedField, b, dt.Scale)
+	if !ok {
+		return decimal128.Num{}, false
+	}
+
+	n, err := ParseDecimal128(text, dt.Precision, scale)
+	return n, nil == err
+}
+
+func ParseDecimal256Field(fixedField *FixedField, b []byte) (decimal256.Num, bool) {
+	dt := fixedField.DestinField.Type.(*arrow.Decimal256Type)
+	text, scale, ok := decimalScale(fixedField, b, dt.Scale)
+	if !ok {
+		return decimal256.Num{}, false
+	}
+
+	n, err := ParseDecimal256(text, dt.Precision, scale)
+	return n, nil == err
+}
+
+// ParseDurationField parses an integer count of the unit of the destination type, or a Go duration like "1h30m".
+func ParseDurationField(fixedField *FixedField, b []byte) (arrow.Duration, bool) {
+	text := strings.TrimSpace(bytesToString(b))
+	if i, ok := parseIntBytes(stringToBytes(text), math.MinInt64, math.MaxInt64); ok {
+		return arrow.Duration(i), true
+	}
+
+	d, err := time.ParseDuration(text)
+	if nil != err {
+		return 0, false
+	}
+
+	unit := fixedField.DestinField.Type.(*arrow.DurationType).Unit
+	return arrow.Duration(d / unit.Multiplier()), true
+}
+
+// ParseMonthIntervalField parses a count of months or an ISO 8601 duration with years and months, ie P1Y2M.
+func ParseMonthIntervalField(fixedField *FixedField, b []byte) (arrow.MonthInterval, bool) {
+	text := strings.TrimSpace(bytesToString(b))
+	if i, ok := parseIntBytes(stringToBytes(text), math.MinInt32, math.MaxInt32); ok {
+		return arrow.MonthInterval(i), true
+	}
+
+	months, days, nanos, ok := parseISODuration(text)
+	if !ok || 0 != days || 0 != nanos || months != int64(int32(months)) {
+		return 0, false
+	}
+	return arrow.MonthInterval(months), true
+}
+
+// ParseDayTimeIntervalField parses a count of days or an ISO 8601 duration without years and months, ie P3DT4H.
+func ParseDayTimeIntervalField(fixedField *FixedField, b []byte) (arrow.DayTimeInterval, bool) {
+	text := strings.TrimSpace(bytesToString(b))
+	if i, ok := parseIntBytes(stringToBytes(text), math.MinInt32, math.MaxInt32); ok {
+		return arrow.DayTimeInterval{Days: int32(i)}, true
+	}
+
+	months, days, nanos, ok := parseISODuration(text)
+	millis := nanos / int64(time.Millisecond)
+	if !ok || 0 != months || days != int64(int32(days)) || millis != int64(int32(millis)) {
+		return arrow.DayTimeInterval{}, false
+	}
+	return arrow.DayTimeInterval{Days: int32(days), Milliseconds: int32(millis)}, true
+}
+
+// ParseMonthDayNanoIntervalField parses an ISO 8601 duration, ie P1Y2M3DT4H5M6.789S.
+func ParseMonthDayNanoIntervalField(fixedField *FixedField, b []byte) (arrow.MonthDayNanoInterval, bool) {
+	months, days, nanos, ok := parseISODuration(strings.TrimSpace(bytesToString(b)))
+	if !ok || months != int64(int32(months)) || days != int64(int32(days)) {
+		return arrow.MonthDayNanoInterval{}, false
+	}
+	return arrow.MonthDayNanoInterval{Months: int32(months), Days: int32(days), Nanoseconds: nanos}, true
+}
+
+// parseISODuration parses an ISO 8601 duration like -P1Y2M3W4DT5H6M7.5S into months, days and nanoseconds.
+func parseISODuration(s string) (int64, int64, int64, bool) {
+	negative := false
+	if len(s) > 0 && ('-' == s[0] || '+' == s[0]) {
+		negative = '-' == s[0]
+		s = s[1:]
+	}
+	if len(s) < 2 || 'P' != s[0] {
+		return 0, 0, 0, false
+	}
+	s = s[1:]
+
+	var months, days, nanos int64
+	inTime := false
+	for len(s) > 0 {
+		if 'T' == s[0] && !inTime {
+			inTime = true
+			s = s[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || '.' == s[i]) {
+			i++
+		}
+		if 0 == i || i == len(s) {
+			return 0, 0, 0, false
+		}
+		number, designator := s[:i], s[i]
+		s = s[i+1:]
+
+		// Only seconds may have a fraction
+		if inTime && 'S' == designator {
+			f, err := strconv.ParseFloat(number, 64)
+			if nil != err {
+				return 0, 0, 0, false
+			}
+			nanos += int64(math.Round(f * float64(time.Second)))
+			continue
+		}
+		n, ok := parseUintBytes(stringToBytes(number), math.MaxInt32)
+		if !ok {
+			return 0, 0, 0, false
+		}
+
+		switch {
+		case !inTime && 'Y' == designator:
+			months += int64(n) * 12
+		case !inTime && 'M' == designator:
+			months += int64(n)
+		case !inTime && 'W' == designator:
+			days += int64(n) * 7
+		case !inTime && 'D' == designator:
+			days += int64(n)
+		case inTime && 'H' == designator:
+			nanos += int64(n) * int64(time.Hour)
+		case inTime && 'M' == designator:
+			nanos += int64(n) * int64(time.Minute)
+		default:
+			return 0, 0, 0, false
+		}
+	}
+
+	if negative {
+		return -months, -days, -nanos, true
+	}
+	return months, days, nanos, true
+}
@@ -23,28 +23,28 @@ import (
 	"github.com/apache/arrow/go/v13/arrow/array"
 )
 
+// ColumnBuilderString appends straight into the value buffer and offsets of the arrow builder.
 type ColumnBuilderString struct {
 	fixedField    *FixedField
 	recordBuilder *array.RecordBuilder
 	fieldnr       int
-	values        []string
-	valid         []bool
+	builder       *array.StringBuilder
 }
 
 func (c *ColumnBuilderString) ParseValue(name string) bool {
+	c.builder.Append(name)
+	return true
+}
 
-	c.values = append(c.values, name)
-	c.valid = append(c.valid, true)
-
+func (c *ColumnBuilderString) ParseBytes(b []byte) bool {
+	c.builder.BinaryBuilder.Append(b)
 	return true
 }
 
 func (c *ColumnBuilderString) FinishColumn() bool {
-	c.recordBuilder.Field(c.fieldnr).(*array.StringBuilder).AppendValues(c.values, c.valid)
 	return true
 }
 
 func (c *ColumnBuilderString) Nullify() {
-	c.values = append(c.values, "")
-	c.valid = append(c.valid, false)
+	c.builder.AppendNull()
 }
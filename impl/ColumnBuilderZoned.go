@@ -0,0 +1,292 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"errors"
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/decimal128"
+	"math"
+	"strconv"
+)
+
+// Source only types. They describe how a field is stored in the fixed file and never reach an arrow schema,
+// so their ids are picked well above the range used by arrow.Type.
+const (
+	ZONED_DECIMAL arrow.Type = 200 + iota
+)
+
+type ZonedSign int
+
+const (
+	// Sign is overpunched into the zone of the last digit, '{ABCDEFGHI' for +0..+9 and '}JKLMNOPQR' for -0..-9.
+	SignOverpunchTrailing ZonedSign = iota
+	// Sign is overpunched into the zone of the first digit.
+	SignOverpunchLeading
+	// Separate '+'/'-' character after the digits.
+	SignSeparateTrailing
+	// Separate '+'/'-' character before the digits.
+	SignSeparateLeading
+)
+
+// ZonedDecimalType is used as FixedField.SourceType for zoned decimal (overpunch) numbers.
+// The destination type decides what is built, Int8/Int16/Int32/Int64, Float32/Float64 and Decimal128 are supported.
+// Scale is the number of implied decimals in the source field.
+// With Ebcdic the zone and sign nibbles are read from the raw EBCDIC bytes, before any SourceEncoding decoding.
+// Set SourceEncoding to the EBCDIC code page anyway, so the other fields and the line terminator are decoded.
+type ZonedDecimalType struct {
+	Sign   ZonedSign
+	Scale  int32
+	Ebcdic bool
+}
+
+func (t *ZonedDecimalType) ID() arrow.Type { return ZONED_DECIMAL }
+func (t *ZonedDecimalType) Name() string   { return "zoned_decimal" }
+func (t *ZonedDecimalType) String() string {
+	return "zoned_decimal(sign=" + strconv.Itoa(int(t.Sign)) + ", scale=" + strconv.Itoa(int(t.Scale)) + ", ebcdic=" + strconv.FormatBool(t.Ebcdic) + ")"
+}
+func (t *ZonedDecimalType) Fingerprint() string          { return t.String() }
+func (t *ZonedDecimalType) Layout() arrow.DataTypeLayout { return arrow.DataTypeLayout{} }
+
+var errZoned = errors.New("not a zoned decimal")
+
+type ColumnBuilderZoned struct {
+	fixedField    *FixedField
+	recordBuilder *array.RecordBuilder
+	fieldnr       int
+	zonedType     *ZonedDecimalType
+	appendNum     func(n decimal128.Num) bool
+}
+
+// ColumnBuilderZonedEbcdic is the ColumnBuilderZoned of an Ebcdic field, a RawColumnBuilder so it gets the bytes
+// as read from the file.
+type ColumnBuilderZonedEbcdic struct {
+	ColumnBuilderZoned
+}
+
+func newColumnBuilderZoned(fixedField *FixedField, builder *array.RecordBuilder, fieldNr int) ColumnBuilder {
+	c := newColumnBuilderZonedText(fixedField, builder, fieldNr)
+	if c.zonedType.Ebcdic {
+		return &ColumnBuilderZonedEbcdic{*c}
+	}
+	return c
+}
+
+func newColumnBuilderZonedText(fixedField *FixedField, builder *array.RecordBuilder, fieldNr int) *ColumnBuilderZoned {
+	c := &ColumnBuilderZoned{fixedField: fixedField, recordBuilder: builder, zonedType: fixedField.SourceType.(*ZonedDecimalType), fieldnr: fieldNr}
+	scale := c.zonedType.Scale
+
+	switch b := builder.Field(fieldNr).(type) {
+	case *array.Decimal128Builder:
+		destScale := fixedField.DestinField.Type.(*arrow.Decimal128Type).Scale
+		c.appendNum = func(n decimal128.Num) bool {
+			r, err := n.Rescale(scale, destScale)
+			if nil != err {
+				return false
+			}
+			b.Append(r)
+			return true
+		}
+	case *array.Float64Builder:
+		c.appendNum = func(n decimal128.Num) bool {
+			b.Append(n.ToFloat64(0) / math.Pow10(int(scale)))
+			return true
+		}
+	case *array.Float32Builder:
+		c.appendNum = func(n decimal128.Num) bool {
+			b.Append(float32(n.ToFloat64(0) / math.Pow10(int(scale))))
+			return true
+		}
+	case *array.Int64Builder:
+		c.appendNum = zonedIntAppender(scale, math.MinInt64, math.MaxInt64, func(i int64) { b.Append(i) })
+	case *array.Int32Builder:
+		c.appendNum = zonedIntAppender(scale, math.MinInt32, math.MaxInt32, func(i int64) { b.Append(int32(i)) })
+	case *array.Int16Builder:
+		c.appendNum = zonedIntAppender(scale, math.MinInt16, math.MaxInt16, func(i int64) { b.Append(int16(i)) })
+	case *array.Int8Builder:
+		c.appendNum = zonedIntAppender(scale, math.MinInt8, math.MaxInt8, func(i int64) { b.Append(int8(i)) })
+	}
+
+	return c
+}
+
+// Implied decimals are truncated for integer destinations, values out of range become null.
+func zonedIntAppender(scale int32, min int64, max int64, appendValue func(int64)) func(n decimal128.Num) bool {
+	minNum := decimal128.FromI64(min)
+	maxNum := decimal128.FromI64(max)
+
+	return func(n decimal128.Num) bool {
+		if scale > 0 {
+			n = n.ReduceScaleBy(scale, false)
+		}
+		if n.Less(minNum) || n.Greater(maxNum) {
+			return false
+		}
+		appendValue(int64(n.LowBits()))
+		return true
+	}
+}
+
+func (c *ColumnBuilderZoned) ParseValue(name string) bool {
+	if nil == c.appendNum {
+		c.Nullify()
+		return false
+	}
+
+	n, err := ParseZoned(name, c.zonedType)
+	if nil != err || !c.appendNum(n) {
+		c.Nullify()
+		return false
+	}
+
+	return true
+}
+
+// The parse functions never keep the string, so the bytes are parsed without a copy.
+func (c *ColumnBuilderZoned) ParseBytes(b []byte) bool {
+	return c.ParseValue(bytesToString(b))
+}
+
+// The parse functions never keep the string, so the bytes are parsed without a copy.
+func (c *ColumnBuilderZonedEbcdic) ParseRaw(raw []byte) bool {
+	return c.ParseValue(bytesToString(raw))
+}
+
+// Fails for destination types a zoned decimal can not be converted to.
+func (c *ColumnBuilderZoned) FinishColumn() bool {
+	return nil != c.appendNum
+}
+
+func (c *ColumnBuilderZoned) Nullify() {
+	c.recordBuilder.Field(c.fieldnr).AppendNull()
+}
+
+// ParseZoned returns the unscaled value of a zoned decimal field. Surrounding blanks are ignored.
+func ParseZoned(field string, zt *ZonedDecimalType) (decimal128.Num, error) {
+	var blank, plus, minus byte = ' ', '+', '-'
+	if zt.Ebcdic {
+		blank, plus, minus = 0x40, 0x4e, 0x60
+	}
+
+	p1 := 0
+	p2 := len(field)
+	for p1 < p2 && field[p1] == blank {
+		p1++
+	}
+	for p2 > p1 && field[p2-1] == blank {
+		p2--
+	}
+	digits := field[p1:p2]
+
+	negative := false
+	switch zt.Sign {
+	case SignSeparateLeading:
+		if len(digits) > 0 && (digits[0] == plus || digits[0] == minus) {
+			negative = digits[0] == minus
+			digits = digits[1:]
+		}
+	case SignSeparateTrailing:
+		if len(digits) > 0 && (digits[len(digits)-1] == plus || digits[len(digits)-1] == minus) {
+			negative = digits[len(digits)-1] == minus
+			digits = digits[:len(digits)-1]
+		}
+	}
+
+	// 38 digits is the maximum precision of a Decimal128
+	if 0 == len(digits) || len(digits) > 38 {
+		return decimal128.Num{}, errZoned
+	}
+
+	signPos := -1
+	switch zt.Sign {
+	case SignOverpunchTrailing:
+		signPos = len(digits) - 1
+	case SignOverpunchLeading:
+		signPos = 0
+	}
+
+	var result decimal128.Num
+	ten := decimal128.FromU64(10)
+
+	for i := 0; i < len(digits); i++ {
+		var digit uint64
+		var neg bool
+		var ok bool
+
+		if zt.Ebcdic {
+			digit, neg, ok = zonedDigitEbcdic(digits[i], i == signPos)
+		} else {
+			digit, neg, ok = zonedDigitAscii(digits[i], i == signPos)
+		}
+		if !ok {
+			return decimal128.Num{}, errZoned
+		}
+		negative = negative || neg
+		result = result.Mul(ten).Add(decimal128.FromU64(digit))
+	}
+
+	if negative {
+		result = result.Negate()
+	}
+	return result, nil
+}
+
+func zonedDigitAscii(b byte, signed bool) (uint64, bool, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return uint64(b - '0'), false, true
+	case !signed:
+		return 0, false, false
+	case b == '{':
+		return 0, false, true
+	case b >= 'A' && b <= 'I':
+		return uint64(b-'A') + 1, false, true
+	case b == '}':
+		return 0, true, true
+	case b >= 'J' && b <= 'R':
+		return uint64(b-'J') + 1, true, true
+	}
+	return 0, false, false
+}
+
+// EBCDIC digits are 0xF0-0xF9, a signed digit carries the sign in the high nibble, 0xC positive and 0xD negative.
+func zonedDigitEbcdic(b byte, signed bool) (uint64, bool, bool) {
+	digit := uint64(b & 0x0f)
+	zone := b >> 4
+
+	if digit > 9 {
+		return 0, false, false
+	}
+	if 0x0f == zone {
+		return digit, false, true
+	}
+	if !signed {
+		return 0, false, false
+	}
+
+	switch zone {
+	case 0x0c, 0x0a, 0x0e:
+		return digit, false, true
+	case 0x0d, 0x0b:
+		return digit, true, true
+	}
+	return 0, false, false
+}
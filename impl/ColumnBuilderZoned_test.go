@@ -0,0 +1,149 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseZoned(t *testing.T) {
+	tests := []struct {
+		field string
+		zt    ZonedDecimalType
+		want  int64
+		err   bool
+	}{
+		{"00042", ZonedDecimalType{Sign: SignOverpunchTrailing}, 42, false},
+		{"  42 ", ZonedDecimalType{Sign: SignOverpunchTrailing}, 42, false},
+		{"0012{", ZonedDecimalType{Sign: SignOverpunchTrailing}, 120, false},
+		{"0012A", ZonedDecimalType{Sign: SignOverpunchTrailing}, 121, false},
+		{"0012I", ZonedDecimalType{Sign: SignOverpunchTrailing}, 129, false},
+		{"0012}", ZonedDecimalType{Sign: SignOverpunchTrailing}, -120, false},
+		{"0012R", ZonedDecimalType{Sign: SignOverpunchTrailing}, -129, false},
+		{"J0012", ZonedDecimalType{Sign: SignOverpunchLeading}, -10012, false},
+		{"A0012", ZonedDecimalType{Sign: SignOverpunchLeading}, 10012, false},
+		{"0012-", ZonedDecimalType{Sign: SignSeparateTrailing}, -12, false},
+		{"0012+", ZonedDecimalType{Sign: SignSeparateTrailing}, 12, false},
+		{"-0012", ZonedDecimalType{Sign: SignSeparateLeading}, -12, false},
+		{"\xf0\xf1\xf2\xc3", ZonedDecimalType{Sign: SignOverpunchTrailing, Ebcdic: true}, 123, false},
+		{"\xf0\xf1\xf2\xd3", ZonedDecimalType{Sign: SignOverpunchTrailing, Ebcdic: true}, -123, false},
+		{"\x40\xf1\xf2\xf3", ZonedDecimalType{Sign: SignOverpunchTrailing, Ebcdic: true}, 123, false},
+		{"\x60\xf1\xf2\xf3", ZonedDecimalType{Sign: SignSeparateLeading, Ebcdic: true}, -123, false},
+		{"", ZonedDecimalType{Sign: SignOverpunchTrailing}, 0, true},
+		{"01J2", ZonedDecimalType{Sign: SignOverpunchTrailing}, 0, true},
+		{"12-", ZonedDecimalType{Sign: SignOverpunchTrailing}, 0, true},
+		{"\xf1\xd2\xf3", ZonedDecimalType{Sign: SignOverpunchTrailing, Ebcdic: true}, 0, true},
+	}
+
+	for _, tt := range tests {
+		n, err := ParseZoned(tt.field, &tt.zt)
+		if tt.err {
+			if nil == err {
+				t.Errorf("ParseZoned(%q) = %v, want an error", tt.field, n)
+			}
+			continue
+		}
+		if nil != err || n.LowBits() != uint64(tt.want) || n.HighBits() != tt.want>>63 {
+			t.Errorf("ParseZoned(%q) = %v, %v, want %d", tt.field, n.BigInt(), err, tt.want)
+		}
+	}
+}
+
+func TestZonedScale(t *testing.T) {
+	zt := &ZonedDecimalType{Sign: SignOverpunchTrailing, Scale: 2}
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 5, DestinField: arrow.Field{Name: "dec", Type: &arrow.Decimal128Type{Precision: 9, Scale: 3}, Nullable: true}, SourceType: zt},
+		{Len: 5, DestinField: arrow.Field{Name: "float", Type: arrow.PrimitiveTypes.Float64, Nullable: true}, SourceType: zt},
+		{Len: 5, DestinField: arrow.Field{Name: "int", Type: arrow.PrimitiveTypes.Int32, Nullable: true}, SourceType: zt},
+	}}
+	data := []byte("0123}0123}0123}\n0000A0000A0000A\n")
+
+	fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF}
+	var reader io.Reader = bytes.NewReader(data)
+	if err := CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(data))); nil != err {
+		t.Fatal(err)
+	}
+	defer fst.releaseRecords()
+
+	record := fst.Records[0][0]
+	want := [][]string{{"-12.3", "-12.3", "-12"}, {"0.01", "0.01", "0"}}
+	for r := range want {
+		for c := range want[r] {
+			if got := record.Column(c).ValueStr(r); got != want[r][c] {
+				t.Errorf("row %d column %s = %s, want %s", r, record.ColumnName(c), got, want[r][c])
+			}
+		}
+	}
+}
+
+func TestZonedEbcdicRecord(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 5, DestinField: arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: 5, DestinField: arrow.Field{Name: "amount", Type: &arrow.Decimal128Type{Precision: 9, Scale: 2}, Nullable: true},
+			SourceType: &ZonedDecimalType{Sign: SignOverpunchTrailing, Scale: 2, Ebcdic: true}},
+		{Len: 3, DestinField: arrow.Field{Name: "count", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+			SourceType: &ZonedDecimalType{Sign: SignOverpunchTrailing, Ebcdic: true}},
+	}}
+
+	encoder := charmap.CodePage037.NewEncoder()
+	var data []byte
+	for _, line := range []struct{ name, amount, count string }{
+		{"ALPHA", "\xf0\xf1\xf2\xf3\xc4", "\xf0\xf4\xf2"},
+		{"BETA ", "\xf0\xf1\xf2\xf3\xd4", "\xf0\xf0\xd7"},
+	} {
+		name, err := encoder.Bytes([]byte(line.name))
+		if nil != err {
+			t.Fatal(err)
+		}
+		data = append(data, name...)
+		data = append(data, line.amount...)
+		data = append(data, line.count...)
+		data = append(data, 0x15)
+	}
+
+	fst := &FixedSizeTable{Cores: 1, SourceEncoding: "ibm037", LineTerminator: TerminatorNEL}
+	var reader io.Reader = bytes.NewReader(data)
+	if err := CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(data))); nil != err {
+		t.Fatal(err)
+	}
+	defer fst.releaseRecords()
+
+	if 2 != fst.LinesParsed {
+		t.Fatalf("LinesParsed = %d, want 2", fst.LinesParsed)
+	}
+	record := fst.Records[0][0]
+	names := record.Column(0).(*array.String)
+	want := [][]string{{"ALPHA", "12.34", "42"}, {"BETA ", "-12.34", "-7"}}
+	for r := range want {
+		if names.Value(r) != want[r][0] {
+			t.Errorf("row %d name = %q, want %q", r, names.Value(r), want[r][0])
+		}
+		for c := 1; c < 3; c++ {
+			if got := record.Column(c).ValueStr(r); got != want[r][c] {
+				t.Errorf("row %d column %s = %s, want %s", r, record.ColumnName(c), got, want[r][c])
+			}
+		}
+	}
+}
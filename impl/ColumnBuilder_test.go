@@ -0,0 +1,157 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+const benchLines = 10000
+
+var benchEncodings = []string{"", "iso8859-1"}
+
+func benchRow() *FixedRow {
+	return &FixedRow{FixedField: []FixedField{
+		{Len: 11, DestinField: arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64, Nullable: true}, SourceType: arrow.PrimitiveTypes.Int64},
+		{Len: 20, DestinField: arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: 10, DestinField: arrow.Field{Name: "amount", Type: arrow.PrimitiveTypes.Float64, Nullable: true}, SourceType: arrow.PrimitiveTypes.Float64},
+		{Len: 5, DestinField: arrow.Field{Name: "count", Type: arrow.PrimitiveTypes.Int32, Nullable: true}, SourceType: arrow.PrimitiveTypes.Int32},
+		{Len: 26, DestinField: arrow.Field{Name: "at", Type: arrow.FixedWidthTypes.Date64, Nullable: true}, SourceType: arrow.FixedWidthTypes.Date64},
+	}}
+}
+
+// benchData gives benchLines CRLF terminated lines, every other name holds a Latin-1 byte.
+func benchData() []byte {
+	var b bytes.Buffer
+	for i := 0; i < benchLines; i++ {
+		name := "hello"
+		if 0 == i%2 {
+			name = "h\xe9llo"
+		}
+		fmt.Fprintf(&b, "%011d%-20s%010.3f%05d2000-05-13-09.00.00.%06d\r\n", i, name, float64(i)/7, i%99999, i%999999)
+	}
+	return b.Bytes()
+}
+
+func BenchmarkConsumeBytes(b *testing.B) {
+	lines := bytes.SplitAfter(benchData(), []byte("\r\n"))
+	for i := range lines {
+		lines[i] = bytes.TrimSuffix(lines[i], []byte("\r\n"))
+	}
+
+	for _, enc := range benchEncodings {
+		b.Run("encoding="+enc, func(b *testing.B) {
+			fst := &FixedSizeTable{Cores: 1, SourceEncoding: enc}
+			row := benchRow()
+			if err := fst.prepare(context.Background(), row, int64(benchLines*row.CalRowLength())); nil != err {
+				b.Fatal(err)
+			}
+			fstc := &FixedSizeTableChunk{FixedSizeTable: fst}
+			fstc.createColumBuilders(benchLines * row.CalRowLength())
+			fstc.decoder = fst.newDecoder()
+			fstc.byteTable = fst.byteTable
+			defer fstc.RecordBuilder[0].Release()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fst.ConsumeBytesFunc(lines[i%benchLines], fstc)
+				if benchLines-1 == i%benchLines {
+					fstc.RecordBuilder[0].NewRecord().Release()
+					fstc.reserve()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProcess(b *testing.B) {
+	data := benchData()
+
+	for _, enc := range benchEncodings {
+		b.Run("encoding="+enc, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				fst := &FixedSizeTable{Cores: 1, SourceEncoding: enc}
+				var reader io.Reader = bytes.NewReader(data)
+				if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil != err {
+					b.Fatal(err)
+				}
+				if benchLines != fst.LinesParsed {
+					b.Fatalf("parsed %d lines of %d", fst.LinesParsed, benchLines)
+				}
+				for _, records := range fst.Records {
+					for _, record := range records {
+						record.Release()
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFindLastNL(t *testing.T) {
+	tests := []struct {
+		data   string
+		wantCR int
+		wantLF int
+	}{
+		{"", -1, -1},
+		{"abc", 0, 0},
+		{"abc\n", 0, 4},
+		{"abc\ndef", 0, 4},
+		{"abc\r\ndef", 5, 5},
+		{"abc\r\ndef\r\n", 10, 10},
+		{"abc\r\ndef\n", 5, 9},
+		{"\n", 0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := FindLastNLCR([]byte(tt.data)); got != tt.wantCR {
+			t.Errorf("FindLastNLCR(%q) = %d, want %d", tt.data, got, tt.wantCR)
+		}
+		if got := FindLastNL_NO_CR([]byte(tt.data)); got != tt.wantLF {
+			t.Errorf("FindLastNL_NO_CR(%q) = %d, want %d", tt.data, got, tt.wantLF)
+		}
+	}
+}
+
+// TestFindLastNLChunks cuts LF lines into chunks, every line is parsed once and none is empty.
+func TestFindLastNLChunks(t *testing.T) {
+	data := bytes.ReplaceAll(benchData(), []byte("\r\n"), []byte("\n"))
+
+	for _, cores := range []int{1, 3, 7} {
+		fst := &FixedSizeTable{Cores: cores, FindLastNL: FindLastNL_NO_CR}
+		var reader io.Reader = bytes.NewReader(data)
+		if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil != err {
+			t.Fatalf("%d cores: %v", cores, err)
+		}
+		if benchLines != fst.LinesParsed {
+			t.Errorf("%d cores: parsed %d lines of %d", cores, fst.LinesParsed, benchLines)
+		}
+		fst.releaseRecords()
+	}
+}
@@ -0,0 +1,297 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression of the input. CompressionNone, the zero value, reads the input as is. CompressionAuto looks at
+// the magic bytes, and at the extension of SourceName when they tell nothing.
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionAuto
+	CompressionGzip
+	CompressionZstd
+	CompressionBzip2
+	CompressionXz
+	CompressionLz4
+)
+
+var compressionMagic = []struct {
+	magic       []byte
+	compression Compression
+}{
+	{[]byte{0x1f, 0x8b}, CompressionGzip},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, CompressionZstd},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, CompressionXz},
+	{[]byte{0x04, 0x22, 0x4d, 0x18}, CompressionLz4},
+}
+
+var compressionExtension = map[string]Compression{
+	".gz":   CompressionGzip,
+	".gzip": CompressionGzip,
+	".bgz":  CompressionGzip,
+	".zst":  CompressionZstd,
+	".zstd": CompressionZstd,
+	".bz2":  CompressionBzip2,
+	".xz":   CompressionXz,
+	".lz4":  CompressionLz4,
+}
+
+var compressionNames = map[string]Compression{
+	"none":  CompressionNone,
+	"auto":  CompressionAuto,
+	"gzip":  CompressionGzip,
+	"zstd":  CompressionZstd,
+	"bzip2": CompressionBzip2,
+	"xz":    CompressionXz,
+	"lz4":   CompressionLz4,
+}
+
+// ParseCompression gives the Compression named none, auto, gzip, zstd, bzip2, xz or lz4.
+func ParseCompression(name string) (Compression, error) {
+	if c, ok := compressionNames[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+	return CompressionNone, fmt.Errorf("unknown compression %q", name)
+}
+
+// magicLength is enough of the input to recognize all formats, BGZF included.
+const magicLength = 18
+
+// DetectCompression tells the compression from the first bytes of the input, or else the extension of name.
+func DetectCompression(head []byte, name string) Compression {
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.compression
+		}
+	}
+	if isBzip2(head) {
+		return CompressionBzip2
+	}
+
+	if c, ok := compressionExtension[strings.ToLower(filepath.Ext(name))]; ok {
+		return c
+	}
+	return CompressionNone
+}
+
+func (fst *FixedSizeTable) detectCompression(head []byte) Compression {
+	if CompressionAuto == fst.Compression {
+		return DetectCompression(head, fst.SourceName)
+	}
+	return fst.Compression
+}
+
+// decompress wraps reader in the decompressor of the input, the close func must be called when done.
+// BGZF, zstd and lz4 are decompressed by Cores goroutines. Plain gzip, also with many members, bzip2 and xz are
+// decompressed by one: a gzip member does not tell its length, so the next one is only found by inflating it.
+// Write gzip as BGZF, like bgzip does, to inflate it in parallel.
+func (fst *FixedSizeTable) decompress(reader io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReaderSize(reader, 1<<20)
+	head, _ := br.Peek(magicLength)
+	none := func() {}
+	cores := fst.Cores
+	if cores < 1 {
+		cores = 1
+	}
+
+	switch fst.detectCompression(head) {
+	case CompressionGzip:
+		if isBGZF(head) {
+			r := newBgzfReader(br, cores)
+			return r, r.close, nil
+		}
+		r, err := gzip.NewReader(br)
+		if nil != err {
+			return nil, none, err
+		}
+		return r, func() { r.Close() }, nil
+
+	case CompressionZstd:
+		r, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(cores))
+		if nil != err {
+			return nil, none, err
+		}
+		return r, r.Close, nil
+
+	case CompressionBzip2:
+		return bzip2.NewReader(br), none, nil
+
+	case CompressionXz:
+		r, err := xz.NewReader(br)
+		if nil != err {
+			return nil, none, err
+		}
+		return r, none, nil
+
+	case CompressionLz4:
+		r := lz4.NewReader(br)
+		err := r.Apply(lz4.ConcurrencyOption(cores))
+		if nil != err {
+			return nil, none, err
+		}
+		return r, none, nil
+	}
+
+	return br, none, nil
+}
+
+// isBzip2 tells a bzip2 stream by its header, the block size and the magic of the first block or of the end of
+// an empty stream, "BZh" alone is too likely as plain text.
+func isBzip2(head []byte) bool {
+	if len(head) < 10 || !bytes.HasPrefix(head, []byte("BZh")) || head[3] < '1' || '9' < head[3] {
+		return false
+	}
+	return bytes.Equal(head[4:10], []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}) ||
+		bytes.Equal(head[4:10], []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90})
+}
+
+// isBGZF tells a gzip member with the BC extra field of BGZF, which holds the size of the member.
+func isBGZF(head []byte) bool {
+	return len(head) >= magicLength && 0 != head[3]&0x04 && 'B' == head[12] && 'C' == head[13] && 2 == head[14]
+}
+
+var errBgzf = errors.New("bad BGZF block")
+
+// bgzfReader inflates the blocks of a BGZF file in parallel, the sizes in the headers tell where blocks start.
+type bgzfReader struct {
+	blocks chan chan bgzfBlock
+	done   chan struct{}
+	buf    []byte
+	err    error
+}
+
+type bgzfBlock struct {
+	data []byte
+	err  error
+}
+
+func newBgzfReader(reader io.Reader, cores int) *bgzfReader {
+	if cores < 1 {
+		cores = 1
+	}
+
+	r := &bgzfReader{blocks: make(chan chan bgzfBlock, 4*cores), done: make(chan struct{})}
+	go r.split(reader, make(chan struct{}, cores))
+	return r
+}
+
+// split reads one block at a time and hands it to a goroutine, the results are queued in order.
+func (r *bgzfReader) split(reader io.Reader, slots chan struct{}) {
+	defer close(r.blocks)
+
+	for {
+		header := make([]byte, magicLength)
+		_, err := io.ReadFull(reader, header)
+		if io.EOF == err {
+			return
+		}
+
+		result := make(chan bgzfBlock, 1)
+		select {
+		case r.blocks <- result:
+		case <-r.done:
+			return
+		}
+
+		if nil != err || !isBGZF(header) {
+			result <- bgzfBlock{err: errBgzf}
+			return
+		}
+
+		block := make([]byte, int(binary.LittleEndian.Uint16(header[16:]))+1)
+		copy(block, header)
+		_, err = io.ReadFull(reader, block[magicLength:])
+		if nil != err {
+			result <- bgzfBlock{err: errBgzf}
+			return
+		}
+
+		slots <- struct{}{}
+		go func() {
+			result <- inflateBgzf(block)
+			<-slots
+		}()
+	}
+}
+
+func inflateBgzf(block []byte) bgzfBlock {
+	xlen := int(binary.LittleEndian.Uint16(block[10:]))
+	if 12+xlen+8 > len(block) {
+		return bgzfBlock{err: errBgzf}
+	}
+
+	trailer := block[len(block)-8:]
+	data := make([]byte, 0, binary.LittleEndian.Uint32(trailer[4:]))
+	out := bytes.NewBuffer(data)
+	_, err := io.Copy(out, flate.NewReader(bytes.NewReader(block[12+xlen:len(block)-8])))
+	if nil != err {
+		return bgzfBlock{err: err}
+	}
+
+	if crc32.ChecksumIEEE(out.Bytes()) != binary.LittleEndian.Uint32(trailer) {
+		return bgzfBlock{err: errBgzf}
+	}
+	return bgzfBlock{data: out.Bytes()}
+}
+
+func (r *bgzfReader) Read(p []byte) (int, error) {
+	for 0 == len(r.buf) {
+		if nil != r.err {
+			return 0, r.err
+		}
+
+		result, ok := <-r.blocks
+		if !ok {
+			r.err = io.EOF
+			continue
+		}
+
+		block := <-result
+		r.buf, r.err = block.data, block.err
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// close stops the splitting when the reader is left before the end.
+func (r *bgzfReader) close() {
+	close(r.done)
+}
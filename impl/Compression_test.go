@@ -0,0 +1,216 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+// bzip2Data is "abcde\nfghij\nklmno\n" compressed by bzip2 -9, Go has no bzip2 writer.
+var bzip2Data = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x0a, 0xb7, 0x75, 0x91, 0x00, 0x00,
+	0x01, 0xc1, 0x00, 0x00, 0x10, 0x3f, 0xff, 0xa0, 0x00, 0x22, 0x13, 0x4f, 0x53, 0x21, 0xea, 0x30,
+	0x53, 0x00, 0x04, 0xd2, 0x9c, 0xe4, 0x69, 0xe0, 0x6f, 0x9d, 0x23, 0xdf, 0x9a, 0xb1, 0x77, 0x24,
+	0x53, 0x85, 0x09, 0x00, 0xab, 0x77, 0x59, 0x10,
+}
+
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		head string
+		name string
+		want Compression
+	}{
+		{"\x1f\x8b\x08\x00", "", CompressionGzip},
+		{"\x28\xb5\x2f\xfd", "", CompressionZstd},
+		{"\xfd7zXZ\x00", "", CompressionXz},
+		{"\x04\x22\x4d\x18", "", CompressionLz4},
+		{string(bzip2Data[:10]), "", CompressionBzip2},
+		{"BZh9 is plain text", "", CompressionNone},
+		{"00000000001hello", "data.txt", CompressionNone},
+		{"00000000001hello", "data.txt.GZ", CompressionGzip},
+		{"00000000001hello", "data.zst", CompressionZstd},
+		{"00000000001hello", "data.bz2", CompressionBzip2},
+		{"\x1f\x8b\x08\x00", "data.zst", CompressionGzip}, // The magic goes first
+	}
+
+	for _, tt := range tests {
+		if got := DetectCompression([]byte(tt.head), tt.name); got != tt.want {
+			t.Errorf("DetectCompression(%q, %q) = %d, want %d", tt.head, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for name, want := range compressionNames {
+		if got, err := ParseCompression(name); nil != err || got != want {
+			t.Errorf("ParseCompression(%q) = %d, %v", name, got, err)
+		}
+	}
+	if got, err := ParseCompression("GZIP"); nil != err || CompressionGzip != got {
+		t.Errorf("ParseCompression(GZIP) = %d, %v", got, err)
+	}
+	if _, err := ParseCompression("zip"); nil == err {
+		t.Error("ParseCompression(zip) gives no error")
+	}
+}
+
+// bgzf writes data in BGZF blocks of blockSize bytes.
+func bgzf(data []byte, blockSize int) []byte {
+	var out bytes.Buffer
+	for 0 < len(data) {
+		n := blockSize
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var deflated bytes.Buffer
+		w, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+		w.Write(data[:n])
+		w.Close()
+
+		header := []byte{0x1f, 0x8b, 8, 4, 0, 0, 0, 0, 0, 0xff, 6, 0, 'B', 'C', 2, 0, 0, 0}
+		binary.LittleEndian.PutUint16(header[16:], uint16(len(header)+deflated.Len()+8-1))
+		out.Write(header)
+		out.Write(deflated.Bytes())
+		binary.Write(&out, binary.LittleEndian, crc32.ChecksumIEEE(data[:n]))
+		binary.Write(&out, binary.LittleEndian, uint32(n))
+		data = data[n:]
+	}
+	return out.Bytes()
+}
+
+func compressed(t *testing.T, data []byte, newWriter func(io.Writer) (io.WriteCloser, error)) []byte {
+	var b bytes.Buffer
+	w, err := newWriter(&b)
+	if nil != err {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	if err = w.Close(); nil != err {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestCompressedInput(t *testing.T) {
+	data := benchData()
+	gz := func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+	inputs := map[string][]byte{
+		"gzip":  compressed(t, data, gz),
+		"bgzf":  bgzf(data, 60000),
+		"zstd":  compressed(t, data, func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }),
+		"xz":    compressed(t, data, func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }),
+		"lz4":   compressed(t, data, func(w io.Writer) (io.WriteCloser, error) { return lz4.NewWriter(w), nil }),
+		"plain": data,
+	}
+	half := len(data) / 2
+	half -= half % (len(data) / benchLines)
+	inputs["gzip members"] = append(compressed(t, data[:half], gz), compressed(t, data[half:], gz)...)
+
+	for name, input := range inputs {
+		fst := &FixedSizeTable{Cores: 4, Compression: CompressionAuto, StreamChunkSize: 64 << 10}
+		var reader io.Reader = bytes.NewReader(input)
+		if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(input))); nil != err {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		id := int64(0)
+		for _, record := range fst.Records[0] {
+			for _, v := range record.Column(0).(*array.Int64).Int64Values() {
+				if v != id {
+					t.Errorf("%s: id %d, want %d", name, v, id)
+				}
+				id++
+			}
+		}
+		if benchLines != id || benchLines != fst.LinesParsed {
+			t.Errorf("%s: %d rows and %d lines parsed of %d", name, id, fst.LinesParsed, benchLines)
+		}
+		fst.releaseRecords()
+	}
+}
+
+func TestBzip2Input(t *testing.T) {
+	for _, compression := range []Compression{CompressionAuto, CompressionBzip2} {
+		fst := &FixedSizeTable{Cores: 2, Compression: compression, LineTerminator: TerminatorLF}
+		var reader io.Reader = bytes.NewReader(bzip2Data)
+		if err := CreateFixedSizeTableFromFile(fst, chunkErrorRow(), &reader, int64(len(bzip2Data))); nil != err {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, record := range fst.Records[0] {
+			values := record.Column(0).(*array.String)
+			for i := 0; i < values.Len(); i++ {
+				got = append(got, values.Value(i))
+			}
+		}
+		if 3 != len(got) || "abcde" != got[0] || "klmno" != got[2] {
+			t.Errorf("compression %d: got %q", compression, got)
+		}
+		fst.releaseRecords()
+	}
+}
+
+// TestCompressionNone reads compressed bytes as they are, the zero value does not look at the input.
+func TestCompressionNone(t *testing.T) {
+	fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF}
+	var reader io.Reader = bytes.NewReader(bzip2Data)
+	if err := CreateFixedSizeTableFromFile(fst, chunkErrorRow(), &reader, int64(len(bzip2Data))); nil != err {
+		t.Fatal(err)
+	}
+	if got := fst.Records[0][0].Column(0).(*array.String).Value(0); "BZh91" != got {
+		t.Errorf("first value %q, want the bzip2 header", got)
+	}
+	fst.releaseRecords()
+}
+
+func TestBgzfCorrupt(t *testing.T) {
+	data := benchData()
+	input := bgzf(data, 60000)
+	input[len(input)-6] ^= 0xff // The CRC of the last block
+
+	fst := &FixedSizeTable{Cores: 4, Compression: CompressionAuto}
+	var reader io.Reader = bytes.NewReader(input)
+	if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(input))); errBgzf != err {
+		t.Errorf("got %v, want %v", err, errBgzf)
+		fst.releaseRecords()
+	}
+
+	// Cut in the middle of a block
+	input = bgzf(data, 60000)[:1000]
+	fst = &FixedSizeTable{Cores: 4, Compression: CompressionAuto}
+	reader = bytes.NewReader(input)
+	if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(input))); errBgzf != err {
+		t.Errorf("got %v, want %v", err, errBgzf)
+		fst.releaseRecords()
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// cancelReader cancels the context on its first read, in the middle of a conversion.
+type cancelReader struct {
+	*bytes.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return r.Reader.Read(p)
+}
+
+func (r *cancelReader) ReadAt(p []byte, off int64) (int, error) {
+	r.cancel()
+	return r.Reader.ReadAt(p, off)
+}
+
+func TestCancel(t *testing.T) {
+	data := benchData()
+	size := int64(len(data))
+
+	convert := map[string]func(ctx context.Context, fst *FixedSizeTable, r *cancelReader) error{
+		"file": func(ctx context.Context, fst *FixedSizeTable, r *cancelReader) error {
+			var reader io.Reader = r
+			return CreateFixedSizeTableFromFileContext(ctx, fst, benchRow(), &reader, size)
+		},
+		"readerAt": func(ctx context.Context, fst *FixedSizeTable, r *cancelReader) error {
+			return CreateFixedSizeTableFromReaderAtContext(ctx, fst, benchRow(), r, size)
+		},
+		"stream": func(ctx context.Context, fst *FixedSizeTable, r *cancelReader) error {
+			return CreateFixedSizeTableFromStreamContext(ctx, fst, benchRow(), r)
+		},
+	}
+
+	for name, f := range convert {
+		// Cancelled while reading
+		ctx, cancel := context.WithCancel(context.Background())
+		fst := &FixedSizeTable{Cores: 4}
+		err := f(ctx, fst, &cancelReader{Reader: bytes.NewReader(data), cancel: cancel})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: cancelled conversion returned %v, want %v", name, err, context.Canceled)
+		}
+		if nil != fst.Records || nil != fst.TableChunks {
+			t.Errorf("%s: cancelled conversion kept %d records and %d chunks", name, len(fst.Records), len(fst.TableChunks))
+		}
+
+		// Past the deadline before it starts
+		ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		fst = &FixedSizeTable{Cores: 4}
+		err = f(ctx, fst, &cancelReader{Reader: bytes.NewReader(data), cancel: func() {}})
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expired conversion returned %v, want %v", name, err, context.DeadlineExceeded)
+		}
+
+		// Not cancelled, the same reader converts all lines
+		fst = &FixedSizeTable{Cores: 4}
+		if err = f(context.Background(), fst, &cancelReader{Reader: bytes.NewReader(data), cancel: func() {}}); nil != err {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if benchLines != fst.LinesParsed {
+			t.Errorf("%s: parsed %d lines of %d", name, fst.LinesParsed, benchLines)
+		}
+		fst.releaseRecords()
+	}
+}
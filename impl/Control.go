@@ -0,0 +1,218 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// ControlKind tells what a Control checks. ControlCount and ControlSum compare numbers by value, the field may be
+// decimal, integer or a string of digits like 000042.
+type ControlKind int
+
+const (
+	ControlCount ControlKind = iota // The field holds the number of lines of the body, LinesParsed
+	ControlSum                      // The field holds the sum of Column over the body
+	ControlValue                    // The field holds Value, compared as string
+)
+
+// Control is a check of a header or footer field, parsed by HeaderRow or FooterRow, done after the conversion.
+type Control struct {
+	Kind   ControlKind
+	Field  string // header.<name> or footer.<name>, as in the schema metadata
+	Column string // Decimal or integer column of the body, for ControlSum
+	Value  string // For ControlValue
+}
+
+// reconcile runs the Controls, the failed ones give ControlErrors.
+func (fst *FixedSizeTable) reconcile() error {
+	var controlErrors ControlErrors
+	for _, control := range fst.Controls {
+		got, ok, err := fst.controlField(control.Field)
+		if nil != err {
+			return err
+		}
+
+		var want string
+		switch control.Kind {
+		case ControlCount:
+			want = strconv.Itoa(fst.LinesParsed)
+			ok = ok && nil != got.Rat && 0 == got.Rat.Cmp(big.NewRat(int64(fst.LinesParsed), 1))
+		case ControlSum:
+			var sum *big.Rat
+			var scale int
+			sum, scale, err = fst.columnSum(control.Column)
+			if nil != err {
+				return err
+			}
+			want = sum.FloatString(scale)
+			ok = ok && nil != got.Rat && 0 == got.Rat.Cmp(sum)
+		case ControlValue:
+			want = control.Value
+			ok = ok && got.String() == want
+		}
+
+		if !ok {
+			controlErrors = append(controlErrors, &ControlError{Control: control, Want: want, Got: got.String()})
+		}
+	}
+
+	if 0 < len(controlErrors) {
+		return controlErrors
+	}
+	return nil
+}
+
+// controlValue is a header or footer value, as string and as number when it is one.
+type controlValue struct {
+	Str string
+	Rat *big.Rat
+}
+
+func (v controlValue) String() string {
+	return v.Str
+}
+
+// controlField finds the value of header.<name> or footer.<name>, false when it is null.
+func (fst *FixedSizeTable) controlField(field string) (controlValue, bool, error) {
+	var record arrow.Record
+	name := field
+	switch {
+	case strings.HasPrefix(field, "header."):
+		record, name = fst.HeaderRecord, field[len("header."):]
+	case strings.HasPrefix(field, "footer."):
+		record, name = fst.FooterRecord, field[len("footer."):]
+	default:
+		return controlValue{}, false, fmt.Errorf("control field %s is not header.<name> or footer.<name>", field)
+	}
+	if nil == record {
+		return controlValue{}, false, fmt.Errorf("control field %s has no record, set HasHeader and HeaderRow or HasFooter and FooterRow", field)
+	}
+
+	indices := record.Schema().FieldIndices(name)
+	if 0 == len(indices) {
+		return controlValue{}, false, fmt.Errorf("control field %s is not in the layout", field)
+	}
+	column := record.Column(indices[0])
+	if column.IsNull(0) {
+		return controlValue{Str: "null"}, false, nil
+	}
+	value := controlValue{Str: column.ValueStr(0), Rat: exactValue(column, 0)}
+	if text, ok := column.(*array.String); ok {
+		value.Rat = decimalText(text.Value(0))
+	}
+	return value, true, nil
+}
+
+// decimalText gives the number of a string of digits with an optional sign and decimal point, spaces around
+// left out, nil for other strings.
+func decimalText(s string) *big.Rat {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 {
+		return nil
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if "" == whole+fraction || strings.Trim(whole+fraction, "0123456789") != "" {
+		return nil
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+// columnSum adds up a decimal or integer column of the body exactly, the scale is the one of the column.
+func (fst *FixedSizeTable) columnSum(column string) (*big.Rat, int, error) {
+	sum := new(big.Rat)
+	scale := 0
+	found := false
+	for t, schema := range fst.Schema {
+		indices := schema.FieldIndices(column)
+		if 0 == len(indices) {
+			continue
+		}
+		found = true
+		if decimal, ok := schema.Field(indices[0]).Type.(arrow.DecimalType); ok && decimal.GetScale() > 0 {
+			scale = int(decimal.GetScale())
+		}
+
+		for _, record := range fst.Records[t] {
+			arr := record.Column(indices[0])
+			for i := 0; i < arr.Len(); i++ {
+				if arr.IsNull(i) {
+					continue
+				}
+				v := exactValue(arr, i)
+				if nil == v {
+					return nil, 0, fmt.Errorf("control column %s is not decimal or integer", column)
+				}
+				sum.Add(sum, v)
+			}
+		}
+	}
+
+	if !found {
+		return nil, 0, errors.New("control column " + column + " is not in the tables")
+	}
+	return sum, scale, nil
+}
+
+// exactValue gives value i of a decimal or integer array, nil for other arrays.
+func exactValue(arr arrow.Array, i int) *big.Rat {
+	switch a := arr.(type) {
+	case *array.Decimal128:
+		return scaled(a.Value(i).BigInt(), a.DataType().(*arrow.Decimal128Type).Scale)
+	case *array.Decimal256:
+		return scaled(a.Value(i).BigInt(), a.DataType().(*arrow.Decimal256Type).Scale)
+	case *array.Int8:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Int16:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Int32:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Int64:
+		return new(big.Rat).SetInt64(a.Value(i))
+	case *array.Uint8:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Uint16:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Uint32:
+		return new(big.Rat).SetInt64(int64(a.Value(i)))
+	case *array.Uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(a.Value(i)))
+	}
+	return nil
+}
+
+func scaled(unscaled *big.Int, scale int32) *big.Rat {
+	if scale < 0 {
+		return new(big.Rat).SetInt(unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil)))
+	}
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
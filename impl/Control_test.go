@@ -0,0 +1,157 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+func controlField(name string, length int, typ arrow.DataType) FixedField {
+	return FixedField{Len: length, DestinField: arrow.Field{Name: name, Type: typ, Nullable: true}, SourceType: typ}
+}
+
+func TestControls(t *testing.T) {
+	decimal := &arrow.Decimal128Type{Precision: 12, Scale: 0}
+	header := &FixedRow{FixedField: []FixedField{
+		controlField("type", 3, arrow.BinaryTypes.String),
+		controlField("date", 19, arrow.FixedWidthTypes.Date32),
+	}}
+	footer := &FixedRow{FixedField: []FixedField{
+		controlField("type", 3, arrow.BinaryTypes.String),
+		controlField("count", 8, arrow.PrimitiveTypes.Int64),
+		controlField("countText", 8, arrow.BinaryTypes.String),
+		controlField("countDate", 19, arrow.FixedWidthTypes.Date32),
+		controlField("total", 12, decimal),
+		controlField("totalText", 12, arrow.BinaryTypes.String),
+		controlField("empty", 5, arrow.PrimitiveTypes.Int64),
+	}}
+	// The body is 100 lines of ids 0 to 99, they add up to 4950
+	footerLine := func(count int, countText int, total int) string {
+		return fmt.Sprintf("TRL%08d%08d2024-01-31-00.00.00%012d%12s%5s", count, countText, total, "+4950.00", "")
+	}
+
+	count := func(field string) Control { return Control{Kind: ControlCount, Field: field} }
+	sum := func(field string) Control { return Control{Kind: ControlSum, Field: field, Column: "id"} }
+	tests := []struct {
+		name     string
+		controls []Control
+		footer   string
+		failed   []string // Fields of the ControlErrors
+	}{
+		{"integer count", []Control{count("footer.count")}, footerLine(100, 100, 4950), nil},
+		{"text count", []Control{count("footer.countText")}, footerLine(100, 100, 4950), nil},
+		{"date count", []Control{count("footer.countDate")}, footerLine(100, 100, 4950), []string{"footer.countDate"}},
+		{"null count", []Control{count("footer.empty")}, footerLine(100, 100, 4950), []string{"footer.empty"}},
+		{"count mismatch", []Control{count("footer.count"), count("footer.countText")},
+			footerLine(99, 101, 4950), []string{"footer.count", "footer.countText"}},
+		{"sum", []Control{sum("footer.total"), sum("footer.totalText")}, footerLine(100, 100, 4950), nil},
+		{"sum mismatch", []Control{sum("footer.total")}, footerLine(100, 100, 4951), []string{"footer.total"}},
+		{"date value", []Control{{Kind: ControlValue, Field: "header.date", Value: "2024-01-31"}}, footerLine(100, 100, 4950), nil},
+		{"date value mismatch", []Control{{Kind: ControlValue, Field: "header.date", Value: "2024-02-01"}}, footerLine(100, 100, 4950), []string{"header.date"}},
+		{"text value", []Control{{Kind: ControlValue, Field: "footer.countText", Value: "00000100"}}, footerLine(100, 100, 4950), nil},
+	}
+
+	for _, tt := range tests {
+		data := append([]byte("HDR2024-01-31-00.00.00\r\n"), benchData()[:100*(len(benchData())/benchLines)]...)
+		data = append(data, tt.footer...)
+
+		fst := &FixedSizeTable{Cores: 3, HasHeader: true, HasFooter: true, HeaderRow: header, FooterRow: footer, Controls: tt.controls}
+		err := chunkErrorConvert("file", fst, data, benchRow())
+		fst.releaseRecords()
+		if 0 == len(tt.failed) {
+			if nil != err {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+
+		var controlErrors ControlErrors
+		if !errors.As(err, &controlErrors) || len(controlErrors) != len(tt.failed) {
+			t.Errorf("%s: got %v, want errors of %v", tt.name, err, tt.failed)
+			continue
+		}
+		for i, e := range controlErrors {
+			if e.Control.Field != tt.failed[i] {
+				t.Errorf("%s: error %v, want one of %s", tt.name, e, tt.failed[i])
+			}
+		}
+		var controlError *ControlError
+		if !errors.As(err, &controlError) || controlError != controlErrors[0] {
+			t.Errorf("%s: errors.As gives %v", tt.name, controlError)
+		}
+	}
+}
+
+func TestControlMessages(t *testing.T) {
+	footer := &FixedRow{FixedField: []FixedField{controlField("count", 8, arrow.BinaryTypes.String)}}
+	data := append(benchData()[:10*(len(benchData())/benchLines)], "00000009"...)
+
+	fst := &FixedSizeTable{Cores: 1, HasFooter: true, FooterRow: footer, Controls: []Control{{Kind: ControlCount, Field: "footer.count"}}}
+	err := chunkErrorConvert("file", fst, data, benchRow())
+	fst.releaseRecords()
+	if want := "control footer.count is 00000009, want 10"; nil == err || want != err.Error() {
+		t.Errorf("got %v, want %s", err, want)
+	}
+
+	for _, field := range []string{"count", "footer.missing", "header.count"} {
+		fst = &FixedSizeTable{Cores: 1, HasFooter: true, FooterRow: footer, Controls: []Control{{Kind: ControlCount, Field: field}}}
+		err = chunkErrorConvert("file", fst, data, benchRow())
+		var controlErrors ControlErrors
+		if nil == err || errors.As(err, &controlErrors) {
+			t.Errorf("%s: got %v, want a configuration error", field, err)
+		}
+		fst.releaseRecords()
+	}
+}
+
+func TestDecimalText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // Empty for nil
+	}{
+		{"000042", "42"},
+		{"  -12.50 ", "-25/2"},
+		{"+7", "7"},
+		{".5", "1/2"},
+		{"", ""},
+		{"-", ""},
+		{"+-1", ""},
+		{"1/2", ""},
+		{"1e3", ""},
+		{"12 34", ""},
+		{"abc", ""},
+	}
+
+	for _, tt := range tests {
+		got := decimalText(tt.text)
+		if "" == tt.want {
+			if nil != got {
+				t.Errorf("decimalText(%q) = %v, want nil", tt.text, got)
+			}
+			continue
+		}
+		if nil == got || tt.want != got.RatString() {
+			t.Errorf("decimalText(%q) = %v, want %s", tt.text, got, tt.want)
+		}
+	}
+}
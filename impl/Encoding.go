@@ -0,0 +1,148 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// The charmap encodings by their normalized names, ISO 8859-1 stays Latin-1 and not Windows-1252 as in HTML.
+var charmaps = map[string]encoding.Encoding{}
+
+func init() {
+	for _, e := range charmap.All {
+		if c, ok := e.(*charmap.Charmap); ok {
+			charmaps[normalizeEncodingName(c.String())] = c
+		}
+	}
+}
+
+// LookupEncoding finds an encoding of golang.org/x/text by name: charmap names like ISO 8859-15, IANA names
+// and aliases like cp850 and ibm037, and the WHATWG names like shift_jis. Empty and UTF-8 give nil, the bytes
+// need no decoding. UTF-16 without byte order is big endian unless a byte order mark tells otherwise.
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	switch normalizeEncodingName(name) {
+	case "", "utf8":
+		return nil, nil
+	case "utf16", "utf16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	case "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	}
+
+	if e, ok := charmaps[normalizeEncodingName(name)]; ok {
+		return e, nil
+	}
+	if e, err := ianaindex.IANA.Encoding(name); nil == err && nil != e {
+		return e, nil
+	}
+	if e, err := htmlindex.Get(name); nil == err {
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %s", name)
+}
+
+func normalizeEncodingName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// peeksHead tells whether the head of the input is looked at before the conversion, to sniff it or to find a
+// byte order mark.
+func (fst *FixedSizeTable) peeksHead() bool {
+	switch normalizeEncodingName(fst.SourceEncoding) {
+	case "utf8", "utf16", "utf16le", "utf16be":
+		return true
+	}
+	return fst.SniffInput
+}
+
+// skipBOM leaves out the byte order mark of a Unicode SourceEncoding, it tells the byte order of plain utf-16.
+func (fst *FixedSizeTable) skipBOM(head []byte) {
+	name := normalizeEncodingName(fst.SourceEncoding)
+	switch {
+	case "utf8" == name && bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		fst.bomLength = 3
+	case ("utf16" == name || "utf16le" == name) && bytes.HasPrefix(head, []byte("\xff\xfe")):
+		fst.bomLength = 2
+		fst.SourceEncoding = "utf-16le"
+	case ("utf16" == name || "utf16be" == name) && bytes.HasPrefix(head, []byte("\xfe\xff")):
+		fst.bomLength = 2
+		fst.SourceEncoding = "utf-16be"
+	}
+}
+
+// byteTable decodes a single byte charmap with one lookup per byte, without the transform machinery.
+type byteTable struct {
+	utf8  [256][]byte
+	ascii bool // ASCII decodes to itself
+}
+
+// newByteTable gives the byteTable of e, nil when e is not a single byte charmap.
+func newByteTable(e encoding.Encoding) *byteTable {
+	c, ok := e.(*charmap.Charmap)
+	if !ok {
+		return nil
+	}
+
+	t := &byteTable{ascii: true}
+	for i := range t.utf8 {
+		r := c.DecodeByte(byte(i))
+		t.utf8[i] = []byte(string(r))
+		if i < utf8.RuneSelf && rune(i) != r {
+			t.ascii = false
+		}
+	}
+	return t
+}
+
+// plain tells whether raw decodes to itself.
+func (t *byteTable) plain(raw []byte) bool {
+	if !t.ascii {
+		return false
+	}
+	for _, b := range raw {
+		if utf8.RuneSelf <= b {
+			return false
+		}
+	}
+	return true
+}
+
+// decode appends the decoded raw to dst.
+func (t *byteTable) decode(dst, raw []byte) []byte {
+	for _, b := range raw {
+		dst = append(dst, t.utf8[b]...)
+	}
+	return dst
+}
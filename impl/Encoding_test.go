@@ -0,0 +1,102 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestLookupEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		text string // Decoded from "\x80\xa4\xe9"
+	}{
+		{"windows-1252", "€¤é"},
+		{"Windows 1252", "€¤é"},
+		{"iso8859-1", "\u0080¤é"},
+		{"ISO-8859-1", "\u0080¤é"},
+		{"iso8859-15", "\ufffd€é"},
+		{"cp850", "ÇñÚ"},
+		{"koi8-r", "─╓И"},
+	}
+
+	for _, tt := range tests {
+		e, err := LookupEncoding(tt.name)
+		if nil != err {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, _ := e.NewDecoder().String("\x80\xa4\xe9"); got != tt.text {
+			t.Errorf("%s decodes to %q, want %q", tt.name, got, tt.text)
+		}
+	}
+
+	for _, name := range []string{"", "utf-8", "UTF8"} {
+		if e, err := LookupEncoding(name); nil != e || nil != err {
+			t.Errorf("%q gives %v and %v, want no decoding", name, e, err)
+		}
+	}
+	for _, name := range []string{"shift_jis", "utf-16le", "utf-16", "ibm037", "euc-kr"} {
+		if e, err := LookupEncoding(name); nil == e || nil != err {
+			t.Errorf("%q gives %v and %v", name, e, err)
+		}
+	}
+	if _, err := LookupEncoding("latin-42"); nil == err {
+		t.Error("no error for an unknown encoding")
+	}
+}
+
+func TestSourceEncoding(t *testing.T) {
+	row := chunkErrorRow()
+	tests := []struct {
+		encoding string
+		data     string
+		want     string
+	}{
+		{"windows-1252", encoded("caf€s\n", charmap.Windows1252.NewEncoder()), "caf€s"},
+		{"cp850", encoded("ÇÑÜ12\n", charmap.CodePage850.NewEncoder()), "ÇÑÜ12"},
+		{"shift_jis", encoded("日本a\n", japanese.ShiftJIS.NewEncoder()), "日本a"},
+	}
+
+	for _, tt := range tests {
+		fst := &FixedSizeTable{Cores: 1, SourceEncoding: tt.encoding}
+		var reader io.Reader = bytes.NewReader([]byte(tt.data))
+		if err := CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(tt.data))); nil != err {
+			t.Errorf("%s: %v", tt.encoding, err)
+			continue
+		}
+		if got := fst.Records[0][0].Column(0).(*array.String).Value(0); tt.want != strings.TrimRight(got, " ") {
+			t.Errorf("%s: %q, want %q", tt.encoding, got, tt.want)
+		}
+		fst.releaseRecords()
+	}
+
+	fst := &FixedSizeTable{Cores: 1, SourceEncoding: "latin-42"}
+	var reader io.Reader = bytes.NewReader([]byte("abcde\n"))
+	if err := CreateFixedSizeTableFromFile(fst, row, &reader, 6); nil == err || !strings.Contains(err.Error(), "latin-42") {
+		t.Errorf("got %v, want the unknown encoding", err)
+	}
+}
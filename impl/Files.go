@@ -0,0 +1,170 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+// ExpandGlobs gives the files matching the patterns, sorted per pattern. A pattern matching nothing is an error.
+func ExpandGlobs(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if nil != err {
+			return nil, err
+		}
+		if 0 == len(matches) {
+			return nil, fmt.Errorf("no file matches %s", pattern)
+		}
+
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// CreateFixedSizeTableFromFiles converts the files into one table per schema, the records follow the order of
+// the files. At most Cores files are open at a time, and all of them share a pool of Cores workers.
+// Sources holds the header, footer, hash and statistics of every file.
+func CreateFixedSizeTableFromFiles(fst *FixedSizeTable, row *FixedRow, files []string) error {
+	return CreateFixedSizeTableFromFilesContext(context.Background(), fst, row, files)
+}
+
+func CreateFixedSizeTableFromFilesContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, files []string) error {
+	err := fst.prepare(ctx, row, -1)
+	if nil != err {
+		return err
+	}
+
+	fst.pool = make(chan struct{}, fst.Cores)
+	fst.Sources = make([]*FixedSizeTable, len(files))
+	errs := make([]error, len(files))
+	open := make(chan struct{}, fst.Cores)
+	var wg sync.WaitGroup
+
+	for i, name := range files {
+		fst.Sources[i] = fst.newSource(name)
+
+		open <- struct{}{}
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-open }()
+
+			file, err := os.Open(name)
+			if nil != err {
+				errs[i] = err
+				return
+			}
+			defer file.Close()
+
+			errs[i] = CreateFixedSizeTableFromMmapContext(ctx, fst.Sources[i], row, file)
+		}(i, name)
+	}
+	wg.Wait()
+
+	if nil != ctx.Err() {
+		fst.releaseSources()
+		return ctx.Err()
+	}
+	for i, err := range errs {
+		if nil != err {
+			fst.releaseSources()
+			return fmt.Errorf("%s: %w", files[i], err)
+		}
+	}
+
+	fst.Records = make([][]arrow.Record, len(fst.TableColAmount))
+	for _, source := range fst.Sources {
+		for i := range fst.Records {
+			fst.Records[i] = append(fst.Records[i], source.Records[i]...)
+		}
+		source.Records = nil
+
+		fst.DurationToArrow += source.DurationToArrow
+		fst.DurationReadChunk += source.DurationReadChunk
+		fst.DurationToExport += source.DurationToExport
+		fst.LinesParsed += source.LinesParsed
+		fst.ShortLines += source.ShortLines
+		fst.LongLines += source.LongLines
+		fst.RejectedLines += source.RejectedLines
+		fst.SkippedLines += source.SkippedLines
+		fst.addChecksums(source.Checksums)
+	}
+
+	return unifyDictionaries(fst)
+}
+
+// newSource gives the table for one file with the configuration of fst.
+func (fst *FixedSizeTable) newSource(name string) *FixedSizeTable {
+	return &FixedSizeTable{
+		TableColAmount:       fst.TableColAmount,
+		HasHeader:            fst.HasHeader,
+		HasFooter:            fst.HasFooter,
+		HeaderLines:          fst.HeaderLines,
+		FooterLines:          fst.FooterLines,
+		HeaderPattern:        fst.HeaderPattern,
+		FooterPattern:        fst.FooterPattern,
+		SkipBlankLines:       fst.SkipBlankLines,
+		CommentPrefix:        fst.CommentPrefix,
+		HeaderRow:            fst.HeaderRow,
+		FooterRow:            fst.FooterRow,
+		Controls:             fst.Controls,
+		CalcHash:             fst.CalcHash,
+		SourceEncoding:       fst.SourceEncoding,
+		ConsumeLineFunc:      fst.ConsumeLineFunc,
+		ConsumeBytesFunc:     fst.ConsumeBytesFunc,
+		FindLastNL:           fst.givenFindLastNL,
+		LineTerminator:       fst.LineTerminator,
+		RecordLength:         fst.RecordLength,
+		SniffInput:           fst.SniffInput,
+		CustomParams:         fst.CustomParams,
+		NumberLocale:         fst.NumberLocale,
+		MaxRecordRows:        fst.MaxRecordRows,
+		ShortLinePolicy:      fst.ShortLinePolicy,
+		LongLinePolicy:       fst.LongLinePolicy,
+		Compression:          fst.Compression,
+		SourceName:           name,
+		StreamChunkSize:      fst.StreamChunkSize,
+		SourceFileColumn:     fst.SourceFileColumn,
+		Lineage:              fst.Lineage,
+		RowHash:              fst.RowHash,
+		ColumnChecksums:      fst.ColumnChecksums,
+		CustomColumnBuilders: fst.CustomColumnBuilders,
+		Registry:             fst.Registry,
+		Cores:                fst.Cores,
+		ColumnsizeCap:        fst.ColumnsizeCap,
+		pool:                 fst.pool,
+		ingestedAt:           fst.ingestedAt,
+	}
+}
+
+func (fst *FixedSizeTable) releaseSources() {
+	for _, source := range fst.Sources {
+		source.releaseRecords()
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/klauspost/compress/gzip"
+)
+
+// dictionaryString gives the string at i of a dictionary column of strings.
+func dictionaryString(column interface{}, i int) string {
+	dict := column.(*array.Dictionary)
+	return dict.Dictionary().(*array.String).Value(dict.GetValueIndex(i))
+}
+
+// writeFiles splits benchData into files of dir, the second one gzipped, and gives their names.
+func writeFiles(t *testing.T, dir string) ([]string, []int) {
+	data := benchData()
+	lineLength := len(data) / benchLines
+	lines := []int{3000, 3000, 4000}
+	names := []string{"a.txt", "b.txt.gz", "c.txt"}
+
+	var files []string
+	for i, name := range names {
+		part := data[:lines[i]*lineLength]
+		data = data[len(part):]
+		if strings.HasSuffix(name, ".gz") {
+			part = compressed(t, part, func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil })
+		}
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, part, 0644); nil != err {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	return files, lines
+}
+
+func TestExpandGlobs(t *testing.T) {
+	dir := t.TempDir()
+	files, _ := writeFiles(t, dir)
+
+	got, err := ExpandGlobs([]string{filepath.Join(dir, "c*"), filepath.Join(dir, "*.txt*")})
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := append([]string{files[2]}, files...)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err = ExpandGlobs([]string{filepath.Join(dir, "*.csv")}); nil == err {
+		t.Error("no error for a pattern matching nothing")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	files, lines := writeFiles(t, t.TempDir())
+
+	fst := &FixedSizeTable{Cores: 2, Compression: CompressionAuto, SourceFileColumn: "file"}
+	if err := CreateFixedSizeTableFromFiles(fst, benchRow(), files); nil != err {
+		t.Fatal(err)
+	}
+	defer fst.releaseRecords()
+
+	if benchLines != fst.LinesParsed {
+		t.Errorf("parsed %d lines of %d", fst.LinesParsed, benchLines)
+	}
+	for i, source := range fst.Sources {
+		if lines[i] != source.LinesParsed || files[i] != source.SourceName {
+			t.Errorf("source %d is %s with %d lines, want %s with %d", i, source.SourceName, source.LinesParsed, files[i], lines[i])
+		}
+	}
+
+	// The rows follow the order of the files
+	id, file, fileEnd := int64(0), 0, int64(lines[0])
+	for _, record := range fst.Records[0] {
+		indices := record.Schema().FieldIndices("file")
+		if 1 != len(indices) {
+			t.Fatalf("schema %v has no file column", record.Schema())
+		}
+		for i, v := range record.Column(0).(*array.Int64).Int64Values() {
+			for id == fileEnd {
+				file++
+				fileEnd += int64(lines[file])
+			}
+			if v != id {
+				t.Fatalf("id %d, want %d", v, id)
+			}
+			if got := dictionaryString(record.Column(indices[0]), i); got != files[file] {
+				t.Fatalf("row %d is from %s, want %s", id, got, files[file])
+			}
+			id++
+		}
+	}
+}
+
+func TestFilesMissing(t *testing.T) {
+	files, _ := writeFiles(t, t.TempDir())
+	missing := files[1] + ".missing"
+	files[1] = missing
+
+	fst := &FixedSizeTable{Cores: 2, Compression: CompressionAuto}
+	err := CreateFixedSizeTableFromFiles(fst, benchRow(), files)
+	if nil == err || !strings.HasPrefix(err.Error(), missing+": ") || !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("got %v, want the missing file", err)
+	}
+	if nil != fst.Records {
+		t.Error("failed conversion kept records")
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/zeebo/xxh3"
+)
+
+type RowHashAlgorithm int
+
+const (
+	RowHashXXH3   RowHashAlgorithm = iota // uint64 column
+	RowHashSHA256                         // fixed_size_binary[32] column
+)
+
+// RowHash adds a column with a hash of the raw bytes of every line, or of the key Fields only, to every table.
+type RowHash struct {
+	Column    string // Name of the column, empty for none
+	Algorithm RowHashAlgorithm
+	Fields    []string // Destination names of the key fields, in this order, empty for the whole line
+}
+
+func (h RowHash) fields() []arrow.Field {
+	switch {
+	case "" == h.Column:
+		return nil
+	case RowHashSHA256 == h.Algorithm:
+		return []arrow.Field{{Name: h.Column, Type: &arrow.FixedSizeBinaryType{ByteWidth: sha256.Size}}}
+	default:
+		return []arrow.Field{{Name: h.Column, Type: arrow.PrimitiveTypes.Uint64}}
+	}
+}
+
+// keyRanges finds the byte ranges of the key fields in the line.
+func (fst *FixedSizeTable) keyRanges() ([][2]int, error) {
+	var ranges [][2]int
+	for _, name := range fst.RowHash.Fields {
+		pos := 0
+		found := false
+		for _, ff := range fst.Row.FixedField {
+			if name == ff.DestinField.Name {
+				ranges = append(ranges, [2]int{pos, pos + ff.Len})
+				found = true
+				break
+			}
+			pos += ff.Len
+		}
+		if !found {
+			return nil, fmt.Errorf("row hash field %s is not in the row", name)
+		}
+	}
+	return ranges, nil
+}
+
+func (f *FixedSizeTableChunk) createRowHashBuilders() {
+	if "" == f.FixedSizeTable.RowHash.Column {
+		return
+	}
+
+	f.rowHash = make([]array.Builder, len(f.RecordBuilder))
+	for t, rb := range f.RecordBuilder {
+		f.rowHash[t] = rb.Field(f.FixedSizeTable.TableColAmount[t] + len(f.FixedSizeTable.Lineage.fields()))
+	}
+}
+
+// appendRowHash adds the hash of the raw line to every table. Key fields missing from a short line hash as empty.
+func (f *FixedSizeTableChunk) appendRowHash(raw []byte) {
+	fst := f.FixedSizeTable
+	data := raw
+	if 0 < len(fst.rowHashRanges) {
+		f.hashBuf = f.hashBuf[:0]
+		for _, r := range fst.rowHashRanges {
+			if r[1] <= len(raw) {
+				f.hashBuf = append(f.hashBuf, raw[r[0]:r[1]]...)
+			} else if r[0] < len(raw) {
+				f.hashBuf = append(f.hashBuf, raw[r[0]:]...)
+			}
+		}
+		data = f.hashBuf
+	}
+
+	if RowHashSHA256 == fst.RowHash.Algorithm {
+		sum := sha256.Sum256(data)
+		for _, b := range f.rowHash {
+			b.(*array.FixedSizeBinaryBuilder).Append(sum[:])
+		}
+		return
+	}
+
+	sum := xxh3.Hash(data)
+	for _, b := range f.rowHash {
+		b.(*array.Uint64Builder).Append(sum)
+	}
+}
+
+// ColumnChecksum holds order independent checksums of a column, so they can be compared whatever the order
+// of the rows or chunks. The hashes are xxh3 of the values as strings, null values left out.
+type ColumnChecksum struct {
+	Table   int
+	Name    string
+	Count   int64
+	Nulls   int64
+	Sum     *big.Rat // Exact sum of the values of a numeric column, nil for other columns
+	SumHash uint64   // Sum of the hashes, wrapping around
+	XorHash uint64
+}
+
+func (c *ColumnChecksum) add(o ColumnChecksum) {
+	c.Count += o.Count
+	c.Nulls += o.Nulls
+	if nil != o.Sum {
+		if nil == c.Sum {
+			c.Sum = new(big.Rat)
+		}
+		c.Sum.Add(c.Sum, o.Sum)
+	}
+	c.SumHash += o.SumHash
+	c.XorHash ^= o.XorHash
+}
+
+// columnChecksums computes the Checksums of all columns of all tables, a goroutine per column.
+func (fst *FixedSizeTable) columnChecksums() {
+	if !fst.ColumnChecksums {
+		return
+	}
+
+	fst.Checksums = nil
+	for t, schema := range fst.Schema {
+		for _, field := range schema.Fields() {
+			fst.Checksums = append(fst.Checksums, ColumnChecksum{Table: t, Name: field.Name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	i := 0
+	for t, schema := range fst.Schema {
+		for ci := range schema.Fields() {
+			wg.Add(1)
+			go func(c *ColumnChecksum, t int, ci int) {
+				defer wg.Done()
+				for _, record := range fst.Records[t] {
+					c.add(arrayChecksum(record.Column(ci)))
+				}
+			}(&fst.Checksums[i], t, ci)
+			i++
+		}
+	}
+	wg.Wait()
+}
+
+// addChecksums adds the checksums of another part of the same tables.
+func (fst *FixedSizeTable) addChecksums(part []ColumnChecksum) {
+	if nil == fst.Checksums {
+		fst.Checksums = make([]ColumnChecksum, len(part))
+		for i := range part {
+			fst.Checksums[i] = ColumnChecksum{Table: part[i].Table, Name: part[i].Name}
+		}
+	}
+	for i := range part {
+		fst.Checksums[i].add(part[i])
+	}
+}
+
+func arrayChecksum(arr arrow.Array) ColumnChecksum {
+	var c ColumnChecksum
+	c.Count = int64(arr.Len())
+	c.Nulls = int64(arr.NullN())
+
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsNull(i) {
+			continue
+		}
+		h := xxh3.HashString(arr.ValueStr(i))
+		c.SumHash += h
+		c.XorHash ^= h
+		if v := numericValue(arr, i); nil != v {
+			if nil == c.Sum {
+				c.Sum = new(big.Rat)
+			}
+			c.Sum.Add(c.Sum, v)
+		}
+	}
+	return c
+}
+
+// numericValue gives value i of a numeric array exactly, floats by their binary value, nil for NaN, infinities
+// and other arrays.
+func numericValue(arr arrow.Array, i int) *big.Rat {
+	switch a := arr.(type) {
+	case *array.Float32:
+		return new(big.Rat).SetFloat64(float64(a.Value(i)))
+	case *array.Float64:
+		return new(big.Rat).SetFloat64(a.Value(i))
+	case *array.Float16:
+		return new(big.Rat).SetFloat64(float64(a.Value(i).Float32()))
+	}
+	return exactValue(arr, i)
+}
@@ -0,0 +1,155 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/zeebo/xxh3"
+)
+
+func TestRowHash(t *testing.T) {
+	data := benchData()
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\r\n")), []byte("\r\n"))
+
+	tests := []struct {
+		name string
+		hash RowHash
+		want func(line []byte) []byte
+	}{
+		{"xxh3", RowHash{Column: "hash"}, func(line []byte) []byte {
+			return binary.BigEndian.AppendUint64(nil, xxh3.Hash(line))
+		}},
+		{"sha256 keys", RowHash{Column: "hash", Algorithm: RowHashSHA256, Fields: []string{"count", "id"}}, func(line []byte) []byte {
+			sum := sha256.Sum256(append(append([]byte{}, line[41:46]...), line[:11]...))
+			return sum[:]
+		}},
+	}
+
+	for _, tt := range tests {
+		fst := &FixedSizeTable{Cores: 3, RowHash: tt.hash}
+		var reader io.Reader = bytes.NewReader(data)
+		if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil != err {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		row := 0
+		for _, record := range fst.Records[0] {
+			column := record.Column(record.Schema().FieldIndices("hash")[0])
+			for i := 0; i < column.Len(); i++ {
+				var got []byte
+				switch c := column.(type) {
+				case *array.Uint64:
+					got = binary.BigEndian.AppendUint64(nil, c.Value(i))
+				case *array.FixedSizeBinary:
+					got = c.Value(i)
+				}
+				if want := tt.want(lines[row]); !bytes.Equal(got, want) {
+					t.Fatalf("%s: row %d hash %x, want %x", tt.name, row, got, want)
+				}
+				row++
+			}
+		}
+		if benchLines != row {
+			t.Errorf("%s: %d rows of %d", tt.name, row, benchLines)
+		}
+		fst.releaseRecords()
+	}
+
+	fst := &FixedSizeTable{Cores: 1, RowHash: RowHash{Column: "hash", Fields: []string{"nothing"}}}
+	var reader io.Reader = bytes.NewReader(data)
+	if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil == err {
+		t.Error("no error for a key field not in the row")
+		fst.releaseRecords()
+	}
+}
+
+// TestColumnChecksums checks that the checksums do not depend on the order of the rows or the chunks.
+func TestColumnChecksums(t *testing.T) {
+	data := benchData()
+	lines := bytes.SplitAfter(data, []byte("\r\n"))
+	reversed := make([]byte, 0, len(data))
+	for i := len(lines) - 1; i >= 0; i-- {
+		reversed = append(reversed, lines[i]...)
+	}
+
+	checksums := func(data []byte, cores int, size int64) []ColumnChecksum {
+		fst := &FixedSizeTable{Cores: cores, ColumnChecksums: true, StreamChunkSize: 64 << 10}
+		var reader io.Reader = bytes.NewReader(data)
+		if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, size); nil != err {
+			t.Fatal(err)
+		}
+		fst.releaseRecords()
+		return fst.Checksums
+	}
+
+	want := checksums(data, 1, int64(len(data)))
+	for _, got := range [][]ColumnChecksum{
+		checksums(data, 4, int64(len(data))),
+		checksums(reversed, 3, int64(len(data))),
+		checksums(reversed, 2, -1),
+	} {
+		if len(got) != len(want) {
+			t.Fatalf("%d checksums, want %d", len(got), len(want))
+		}
+		for i := range got {
+			g, w := got[i], want[i]
+			if g.Name != w.Name || g.Count != w.Count || g.Nulls != w.Nulls || g.SumHash != w.SumHash || g.XorHash != w.XorHash ||
+				(nil == g.Sum) != (nil == w.Sum) || (nil != g.Sum && 0 != g.Sum.Cmp(w.Sum)) {
+				t.Errorf("checksum %+v, want %+v", g, w)
+			}
+		}
+	}
+
+	byName := map[string]ColumnChecksum{}
+	for _, c := range want {
+		byName[c.Name] = c
+	}
+	if id := byName["id"]; benchLines != id.Count || 0 != id.Nulls || nil == id.Sum || 0 != id.Sum.Cmp(big.NewRat(benchLines*(benchLines-1)/2, 1)) {
+		t.Errorf("id checksum %+v, want the sum %d", id, benchLines*(benchLines-1)/2)
+	}
+	if name := byName["name"]; nil != name.Sum || 0 == name.SumHash {
+		t.Errorf("name checksum %+v, want hashes only", name)
+	}
+	if amount := byName["amount"]; nil == amount.Sum {
+		t.Errorf("amount checksum %+v, want a sum", amount)
+	}
+}
+
+func TestCalcHash(t *testing.T) {
+	data := benchData()
+	want := sha256.Sum256(data)
+
+	for _, engine := range []string{"file", "readerAt", "stream"} {
+		fst := &FixedSizeTable{Cores: 3, CalcHash: true, StreamChunkSize: 64 << 10}
+		if err := chunkErrorConvert(engine, fst, data, benchRow()); nil != err {
+			t.Fatalf("%s: %v", engine, err)
+		}
+		if !bytes.Equal(want[:], fst.Hash) {
+			t.Errorf("%s: hash %x, want %x", engine, fst.Hash, want)
+		}
+		fst.releaseRecords()
+	}
+}
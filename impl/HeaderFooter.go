@@ -0,0 +1,194 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// skipLine tells the blank and comment lines to skip, by the decoded line.
+func (fst *FixedSizeTable) skipLine(line []byte) bool {
+	if fst.SkipBlankLines && 0 == len(bytes.TrimSpace(line)) {
+		return true
+	}
+	return "" != fst.CommentPrefix && bytes.HasPrefix(line, []byte(fst.CommentPrefix))
+}
+
+// isHeaderLine tells whether a decoded line, following n header lines, belongs to the header.
+func (fst *FixedSizeTable) isHeaderLine(line []byte, n int) bool {
+	if nil != fst.HeaderPattern {
+		return fst.HeaderPattern.Match(line)
+	}
+	return n < fst.HeaderLines || (0 == fst.HeaderLines && 0 == n)
+}
+
+// isFooterLine tells whether a decoded line, preceding n footer lines, belongs to the footer.
+func (fst *FixedSizeTable) isFooterLine(line []byte, n int) bool {
+	if nil != fst.FooterPattern {
+		return fst.FooterPattern.Match(line)
+	}
+	return n < fst.FooterLines || (0 == fst.FooterLines && 0 == n)
+}
+
+// addHeader keeps header line n, the first one is parsed by HeaderRow.
+func (fstc *FixedSizeTableChunk) addHeader(line []byte, n int) error {
+	fst := fstc.FixedSizeTable
+	header := fstc.decodeLine(line)
+	fst.Headers = append(fst.Headers, header)
+	if 0 != n {
+		return nil
+	}
+
+	fst.Header = header
+	if nil != fst.HeaderRow {
+		record, err := fst.parseLayout(fst.HeaderRow, line)
+		if nil != err {
+			return fmt.Errorf("header: %w", err)
+		}
+		fst.HeaderRecord = record
+	}
+	return nil
+}
+
+// cutFooter takes the footer lines off the end of the body of the last chunk and gives what is left, the lines found
+// and decoded like those of the body. A terminator after the footer and skipped lines among the footer
+// lines are left out.
+func (fstc *FixedSizeTableChunk) cutFooter(body []byte) ([]byte, error) {
+	fst := fstc.FixedSizeTable
+	var footers []string
+	var footerLines [][]byte
+	for 0 < len(body) {
+		start, end := fst.lastLine(body)
+		line := body[start:end]
+		text := fstc.lineText(line)
+		if fst.skipLine(text) {
+			fstc.SkippedLines++
+			body = body[:start]
+			continue
+		}
+		if !fst.isFooterLine(text, len(footers)) {
+			break
+		}
+		footers = append(footers, fstc.decodeLine(line))
+		footerLines = append(footerLines, line)
+		body = body[:start]
+	}
+
+	if nil == fst.FooterPattern && len(footers) < fst.FooterLines {
+		return nil, fmt.Errorf("no footer of %d lines found", fst.FooterLines)
+	}
+	if 0 == len(footers) {
+		return nil, errors.New("no footer found")
+	}
+
+	for i, j := 0, len(footers)-1; i < j; i, j = i+1, j-1 {
+		footers[i], footers[j] = footers[j], footers[i]
+	}
+	fst.Footers = footers
+	fst.Footer = footers[len(footers)-1]
+	if nil != fst.FooterRow {
+		record, err := fst.parseLayout(fst.FooterRow, footerLines[0]) // Found first
+		if nil != err {
+			return nil, fmt.Errorf("footer: %w", err)
+		}
+		fst.FooterRecord = record
+	}
+	return body, nil
+}
+
+// parseLayout parses a header or footer line with a layout of its own into a record of one row.
+func (fst *FixedSizeTable) parseLayout(row *FixedRow, line []byte) (arrow.Record, error) {
+	layout := &FixedSizeTable{
+		SourceEncoding:       fst.SourceEncoding,
+		FindLastNL:           fst.FindLastNL,
+		NumberLocale:         fst.NumberLocale,
+		ShortLinePolicy:      fst.ShortLinePolicy,
+		LongLinePolicy:       fst.LongLinePolicy,
+		CustomColumnBuilders: fst.CustomColumnBuilders,
+		Registry:             fst.Registry,
+	}
+	err := layout.prepare(fst.ctx, row, int64(len(line)))
+	if nil != err {
+		return nil, err
+	}
+
+	fstc := &FixedSizeTableChunk{FixedSizeTable: layout}
+	fstc.createColumBuilders(len(line))
+	defer fstc.RecordBuilder[0].Release()
+
+	fstc.decoder = layout.newDecoder()
+	fstc.byteTable = layout.byteTable
+	line, ok, err := fstc.fitLine(line)
+	if nil != err {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("line rejected by its layout")
+	}
+	fstc.RawLine = line
+	layout.ConsumeBytesFunc(line, fstc)
+	if err := fstc.builderErr(); nil != err {
+		return nil, err
+	}
+
+	for ci := range row.FixedField {
+		if !fstc.ColumnBuilders[ci].FinishColumn() {
+			return nil, columnError(fstc.ColumnBuilders[ci], row.FixedField[ci].DestinField.Name)
+		}
+	}
+	return fstc.RecordBuilder[0].NewRecord(), nil
+}
+
+// addLayoutMetadata puts the non null header and footer values in the metadata of the schemas, keyed
+// header.<name> and footer.<name>.
+func (fst *FixedSizeTable) addLayoutMetadata() {
+	var keys, values []string
+	for _, layout := range []struct {
+		prefix string
+		record arrow.Record
+	}{{"header.", fst.HeaderRecord}, {"footer.", fst.FooterRecord}} {
+		if nil == layout.record {
+			continue
+		}
+		for i, field := range layout.record.Schema().Fields() {
+			if layout.record.Column(i).IsValid(0) {
+				keys = append(keys, layout.prefix+field.Name)
+				values = append(values, layout.record.Column(i).ValueStr(0))
+			}
+		}
+	}
+	if 0 == len(keys) {
+		return
+	}
+
+	metadata := arrow.NewMetadata(keys, values)
+	for t := range fst.Schema {
+		schema := arrow.NewSchema(fst.Schema[t].Fields(), &metadata)
+		fst.Schema[t] = *schema
+		for j, record := range fst.Records[t] {
+			fst.Records[t][j] = array.NewRecord(schema, record.Columns(), record.NumRows())
+			record.Release()
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+func headerRow() *FixedRow {
+	return &FixedRow{FixedField: []FixedField{
+		{Len: 3, DestinField: arrow.Field{Name: "type", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: 8, DestinField: arrow.Field{Name: "date", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: 6, DestinField: arrow.Field{Name: "sequence", Type: arrow.PrimitiveTypes.Int64, Nullable: true}, SourceType: arrow.PrimitiveTypes.Int64},
+	}}
+}
+
+func footerRow() *FixedRow {
+	return &FixedRow{FixedField: []FixedField{
+		{Len: 3, DestinField: arrow.Field{Name: "type", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: 8, DestinField: arrow.Field{Name: "count", Type: arrow.PrimitiveTypes.Int64, Nullable: true}, SourceType: arrow.PrimitiveTypes.Int64},
+		{Len: 12, DestinField: arrow.Field{Name: "total", Type: &arrow.Decimal128Type{Precision: 12, Scale: 0}, Nullable: true}, SourceType: &arrow.Decimal128Type{Precision: 12, Scale: 0}},
+	}}
+}
+
+// layoutData gives a header line, lines of benchData and a footer line, with the sum of the ids as total.
+func layoutData(lines int, footer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("HDR20240131000042\r\n")
+	b.Write(benchData()[:lines*(len(benchData())/benchLines)])
+	if "" == footer {
+		footer = fmt.Sprintf("TRL%08d%012d", lines, lines*(lines-1)/2)
+	}
+	b.WriteString(footer)
+	return b.Bytes()
+}
+
+func TestHeaderFooterLayout(t *testing.T) {
+	data := layoutData(1000, "")
+
+	for _, engine := range []string{"file", "readerAt", "stream"} {
+		for _, cores := range []int{1, 4} {
+			fst := &FixedSizeTable{Cores: cores, HasHeader: true, HasFooter: true, HeaderRow: headerRow(), FooterRow: footerRow(), StreamChunkSize: 16 << 10}
+			if err := chunkErrorConvert(engine, fst, data, benchRow()); nil != err {
+				t.Fatalf("%s %d: %v", engine, cores, err)
+			}
+
+			if 1000 != fst.LinesParsed {
+				t.Errorf("%s %d: parsed %d lines of 1000", engine, cores, fst.LinesParsed)
+			}
+			if "HDR20240131000042" != fst.Header || !strings.HasPrefix(fst.Footer, "TRL00001000") {
+				t.Errorf("%s %d: header %q and footer %q", engine, cores, fst.Header, fst.Footer)
+			}
+
+			if nil == fst.HeaderRecord || nil == fst.FooterRecord {
+				t.Fatalf("%s %d: header record %v and footer record %v", engine, cores, fst.HeaderRecord, fst.FooterRecord)
+			}
+			if got := fst.HeaderRecord.Column(2).(*array.Int64).Value(0); 42 != got {
+				t.Errorf("%s %d: header sequence %d, want 42", engine, cores, got)
+			}
+			if got := fst.FooterRecord.Column(1).(*array.Int64).Value(0); 1000 != got {
+				t.Errorf("%s %d: footer count %d, want 1000", engine, cores, got)
+			}
+
+			// The values are in the metadata of the schema and the records
+			want := map[string]string{"header.type": "HDR", "header.date": "20240131", "header.sequence": "42", "footer.count": "1000", "footer.total": "499500"}
+			for _, metadata := range []arrow.Metadata{fst.Schema[0].Metadata(), fst.Records[0][0].Schema().Metadata()} {
+				for key, value := range want {
+					if i := metadata.FindKey(key); i < 0 || value != metadata.Values()[i] {
+						t.Errorf("%s %d: metadata %v, want %s=%s", engine, cores, metadata, key, value)
+					}
+				}
+			}
+			fst.releaseRecords()
+		}
+	}
+}
+
+func TestHeaderFooterLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fst  *FixedSizeTable
+		data []byte
+		want string
+	}{
+		{"short header", &FixedSizeTable{HasHeader: true, HeaderRow: footerRow()}, layoutData(10, "TRL"), "header: "},
+		{"short footer", &FixedSizeTable{HasHeader: true, HasFooter: true, FooterRow: footerRow()}, layoutData(10, "TRL"), "footer: "},
+	}
+
+	for _, tt := range tests {
+		tt.fst.Cores = 2
+		err := chunkErrorConvert("file", tt.fst, tt.data, benchRow())
+		if nil == err || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.want)
+		}
+		if nil != tt.fst.Records {
+			t.Errorf("%s: failed conversion kept records", tt.name)
+		}
+	}
+}
+
+func TestHeaderFooterLines(t *testing.T) {
+	body := benchData()[:100*(len(benchData())/benchLines)]
+	text := func(parts ...string) []byte {
+		var b bytes.Buffer
+		for _, part := range parts {
+			if "body" == part {
+				b.Write(body)
+			} else {
+				b.WriteString(part)
+			}
+		}
+		return b.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		fst     FixedSizeTable
+		data    []byte
+		headers []string
+		footers []string
+		skipped int
+	}{
+		{"one each", FixedSizeTable{HasHeader: true, HasFooter: true},
+			text("HDR1\r\n", "body", "TRL1"), []string{"HDR1"}, []string{"TRL1"}, 0},
+		{"trailing line end", FixedSizeTable{HasHeader: true, HasFooter: true},
+			text("HDR1\r\n", "body", "TRL1\r\n"), []string{"HDR1"}, []string{"TRL1"}, 0},
+		{"counted", FixedSizeTable{HasHeader: true, HasFooter: true, HeaderLines: 2, FooterLines: 3},
+			text("HDR1\r\nHDR2\r\n", "body", "TRL1\r\nTRL2\r\nTRL3\r\n"), []string{"HDR1", "HDR2"}, []string{"TRL1", "TRL2", "TRL3"}, 0},
+		{"patterns", FixedSizeTable{HasHeader: true, HasFooter: true, HeaderPattern: regexp.MustCompile("^HDR"), FooterPattern: regexp.MustCompile("^TRL")},
+			text("HDR1\r\nHDR2\r\nHDR3\r\n", "body", "TRL1\r\nTRL2"), []string{"HDR1", "HDR2", "HDR3"}, []string{"TRL1", "TRL2"}, 0},
+		{"skipped lines", FixedSizeTable{HasHeader: true, HasFooter: true, HeaderLines: 2, FooterLines: 2, SkipBlankLines: true, CommentPrefix: "#"},
+			text("# comment\r\nHDR1\r\n   \r\nHDR2\r\n", "body", "# comment\r\n\r\n", "body", "TRL1\r\n# comment\r\nTRL2\r\n\r\n"),
+			[]string{"HDR1", "HDR2"}, []string{"TRL1", "TRL2"}, 6},
+	}
+
+	for _, tt := range tests {
+		for _, cores := range []int{1, 4} {
+			fst := tt.fst
+			fst.Cores = cores
+			if err := chunkErrorConvert("file", &fst, tt.data, benchRow()); nil != err {
+				t.Errorf("%s %d: %v", tt.name, cores, err)
+				continue
+			}
+
+			lines := 100
+			if 0 < tt.skipped {
+				lines = 200
+			}
+			if lines != fst.LinesParsed || tt.skipped != fst.SkippedLines {
+				t.Errorf("%s %d: %d lines parsed and %d skipped, want %d and %d", tt.name, cores, fst.LinesParsed, fst.SkippedLines, lines, tt.skipped)
+			}
+			if strings.Join(fst.Headers, "|") != strings.Join(tt.headers, "|") || fst.Header != tt.headers[0] {
+				t.Errorf("%s %d: headers %q and header %q, want %q", tt.name, cores, fst.Headers, fst.Header, tt.headers)
+			}
+			if strings.Join(fst.Footers, "|") != strings.Join(tt.footers, "|") || fst.Footer != tt.footers[len(tt.footers)-1] {
+				t.Errorf("%s %d: footers %q and footer %q, want %q", tt.name, cores, fst.Footers, fst.Footer, tt.footers)
+			}
+			fst.releaseRecords()
+		}
+	}
+}
+
+func TestFooterMissing(t *testing.T) {
+	body := benchData()[:10*(len(benchData())/benchLines)]
+	tests := []struct {
+		name string
+		fst  FixedSizeTable
+		data []byte
+	}{
+		{"too few lines", FixedSizeTable{HasFooter: true, FooterLines: 3}, []byte("TRL1\r\nTRL2")},
+		{"no match", FixedSizeTable{HasFooter: true, FooterPattern: regexp.MustCompile("^TRL")}, body},
+	}
+
+	for _, tt := range tests {
+		fst := tt.fst
+		fst.Cores = 1
+		if err := chunkErrorConvert("file", &fst, tt.data, benchRow()); nil == err || !strings.Contains(err.Error(), "no footer") {
+			t.Errorf("%s: got %v, want no footer", tt.name, err)
+		}
+	}
+}
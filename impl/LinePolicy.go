@@ -0,0 +1,89 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"fmt"
+)
+
+// ShortLinePolicy tells what to do with a line shorter than the row.
+type ShortLinePolicy int
+
+const (
+	ShortLineError  ShortLinePolicy = iota // Fail the conversion
+	ShortLinePad                           // Pad the line with spaces
+	ShortLineNull                          // Fields not complete in the line are null
+	ShortLineReject                        // Leave the line out
+)
+
+// LongLinePolicy tells what to do with a line longer than the row.
+type LongLinePolicy int
+
+const (
+	LongLineIgnore   LongLinePolicy = iota // Ignore whatever follows the row
+	LongLineError                          // Fail the conversion
+	LongLineReject                         // Leave the line out
+	LongLineOverflow                       // The last field, declared with Len 0, gets whatever follows the row
+)
+
+// fitLine applies the line policies, false means the line is left out.
+func (fstc *FixedSizeTableChunk) fitLine(line []byte) ([]byte, bool, error) {
+	fst := fstc.FixedSizeTable
+	rowLength := fst.Row.CalRowLength() - 2
+
+	switch {
+	case len(line) < rowLength:
+		fstc.ShortLines++
+		switch fst.ShortLinePolicy {
+		case ShortLinePad:
+			fstc.lineBuf = append(fstc.lineBuf[:0], line...)
+			for len(fstc.lineBuf) < rowLength {
+				fstc.lineBuf = append(fstc.lineBuf, fst.space...)
+			}
+			return fstc.lineBuf[:rowLength], true, nil
+		case ShortLineNull:
+			return line, true, nil
+		case ShortLineReject:
+			fstc.RejectedLines++
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("line of %d bytes is shorter than the row of %d", len(line), rowLength)
+		}
+
+	case len(line) > rowLength:
+		fstc.LongLines++
+		switch fst.LongLinePolicy {
+		case LongLineError:
+			return nil, false, fmt.Errorf("line of %d bytes is longer than the row of %d", len(line), rowLength)
+		case LongLineReject:
+			fstc.RejectedLines++
+			return nil, false, nil
+		}
+	}
+
+	return line, true, nil
+}
+
+// fieldEnd is where the field ci starting at columnPos ends in a line of lineLength bytes.
+func (fstc *FixedSizeTableChunk) fieldEnd(ci int, columnPos int, lineLength int) int {
+	if LongLineOverflow == fstc.FixedSizeTable.LongLinePolicy && ci == len(fstc.FixedSizeTable.Row.FixedField)-1 && lineLength > columnPos {
+		return lineLength
+	}
+	return columnPos + fstc.FixedSizeTable.Row.FixedField[ci].Len
+}
@@ -0,0 +1,103 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+func linePolicyRow(lastLen int) *FixedRow {
+	return &FixedRow{FixedField: []FixedField{
+		{Len: 3, DestinField: arrow.Field{Name: "a", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+		{Len: lastLen, DestinField: arrow.Field{Name: "b", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+}
+
+func TestLinePolicy(t *testing.T) {
+	data := []byte("abcde\nab\nabcd\nabcdefg\n")
+	tests := []struct {
+		name     string
+		short    ShortLinePolicy
+		long     LongLinePolicy
+		lastLen  int
+		wantErr  bool
+		want     []string // a|b, - for null
+		rejected int
+	}{
+		{"error", ShortLineError, LongLineIgnore, 2, true, nil, 0},
+		{"pad", ShortLinePad, LongLineIgnore, 2, false, []string{"abc|de", "ab |  ", "abc|d ", "abc|de"}, 0},
+		{"null", ShortLineNull, LongLineIgnore, 2, false, []string{"abc|de", "-|-", "abc|-", "abc|de"}, 0},
+		{"reject", ShortLineReject, LongLineIgnore, 2, false, []string{"abc|de", "abc|de"}, 2},
+		{"long error", ShortLinePad, LongLineError, 2, true, nil, 0},
+		{"long reject", ShortLinePad, LongLineReject, 2, false, []string{"abc|de", "ab |  ", "abc|d "}, 1},
+		{"long overflow", ShortLinePad, LongLineOverflow, 2, false, []string{"abc|de", "ab |  ", "abc|d ", "abc|defg"}, 0},
+	}
+
+	for _, tt := range tests {
+		fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF, ShortLinePolicy: tt.short, LongLinePolicy: tt.long}
+		var reader io.Reader = bytes.NewReader(data)
+		err := CreateFixedSizeTableFromFile(fst, linePolicyRow(tt.lastLen), &reader, int64(len(data)))
+		if tt.wantErr {
+			if nil == err {
+				t.Errorf("%s: no error", tt.name)
+				fst.releaseRecords()
+			}
+			continue
+		}
+		if nil != err {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		var got []string
+		for _, record := range fst.Records[0] {
+			a := record.Column(0).(*array.String)
+			b := record.Column(1).(*array.String)
+			for i := 0; i < int(record.NumRows()); i++ {
+				got = append(got, linePolicyValue(a, i)+"|"+linePolicyValue(b, i))
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		} else {
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("%s: row %d is %q, want %q", tt.name, i, got[i], tt.want[i])
+				}
+			}
+		}
+
+		if 2 != fst.ShortLines || 1 != fst.LongLines || tt.rejected != fst.RejectedLines || 4 != fst.LinesParsed { // Rejected lines are in LinesParsed
+			t.Errorf("%s: %d short, %d long, %d rejected and %d parsed lines", tt.name, fst.ShortLines, fst.LongLines, fst.RejectedLines, fst.LinesParsed)
+		}
+		fst.releaseRecords()
+	}
+}
+
+func linePolicyValue(a *array.String, i int) string {
+	if a.IsNull(i) {
+		return "-"
+	}
+	return a.Value(i)
+}
@@ -0,0 +1,216 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// LineTerminator tells how the lines of the input end, the same for header, body and footer.
+type LineTerminator int
+
+const (
+	TerminatorDefault LineTerminator = iota // LF with an optional CR, in code units for UTF-16, chunks cut by FindLastNL
+	TerminatorCRLF
+	TerminatorLF
+	TerminatorCR
+	TerminatorNEL   // U+0085 in the SourceEncoding, 0x15 in EBCDIC
+	TerminatorFixed // No terminator, every RecordLength bytes is a line
+)
+
+var terminatorText = map[LineTerminator]string{
+	TerminatorCRLF: "\r\n",
+	TerminatorLF:   "\n",
+	TerminatorCR:   "\r",
+	TerminatorNEL:  "\u0085",
+}
+
+// encode gives text in the SourceEncoding, as it is when it can not be encoded.
+func (fst *FixedSizeTable) encode(text []byte) []byte {
+	if nil != fst.encoding {
+		if encoded, err := fst.encoding.NewEncoder().Bytes(text); nil == err {
+			return encoded
+		}
+	}
+	return text
+}
+
+// prepareTerminator finds the bytes of the LineTerminator, and of a space, in the SourceEncoding and lets
+// FindLastNL follow the LineTerminator.
+func (fst *FixedSizeTable) prepareTerminator(row *FixedRow) {
+	fst.space = fst.encode([]byte(" "))
+	fst.unit = len(fst.encode([]byte("\n")))
+	fst.terminator, fst.trimCR = nil, nil
+
+	switch fst.LineTerminator {
+	case TerminatorDefault:
+		if 1 == fst.unit {
+			return
+		}
+		// In UTF-16 LF with an optional CR is framed in code units, the bytes of a character are never a line end
+		fst.terminator = fst.encode([]byte("\n"))
+		fst.trimCR = fst.encode([]byte("\r"))
+	case TerminatorFixed:
+		fst.recordLength = fst.RecordLength
+		if 0 == fst.recordLength {
+			fst.recordLength = row.CalRowLength() - 2
+		}
+	default:
+		fst.terminator = []byte(terminatorText[fst.LineTerminator])
+		fst.terminator = fst.encode(fst.terminator)
+	}
+
+	if nil == fst.FindLastNL {
+		fst.FindLastNL = fst.findLastTerminator
+	}
+}
+
+// findLastTerminator is FindLastNL for the LineTerminator, the position just after the last line.
+func (fst *FixedSizeTable) findLastTerminator(buf []byte) int {
+	if 0 == len(buf) {
+		return -1
+	}
+	if 0 < fst.recordLength {
+		return len(buf) - len(buf)%fst.recordLength
+	}
+
+	p := lastIndexUnit(buf, fst.terminator, fst.unit)
+	if p < 0 {
+		return 0
+	}
+	return p + len(fst.terminator)
+}
+
+// indexUnit is bytes.Index of sep at a whole code unit of unit bytes from the start of b.
+func indexUnit(b []byte, sep []byte, unit int) int {
+	offset := 0
+	for {
+		p := bytes.Index(b[offset:], sep)
+		if p < 0 {
+			return -1
+		}
+		if unit <= 1 || 0 == (offset+p)%unit {
+			return offset + p
+		}
+		offset += p + 1
+	}
+}
+
+// lastIndexUnit is bytes.LastIndex of sep at a whole code unit of unit bytes from the start of b.
+func lastIndexUnit(b []byte, sep []byte, unit int) int {
+	for end := len(b); ; {
+		p := bytes.LastIndex(b[:end], sep)
+		if p < 0 || unit <= 1 || 0 == p%unit {
+			return p
+		}
+		end = p + len(sep) - 1
+	}
+}
+
+// scanLines is the bufio.SplitFunc for the LineTerminator.
+func (fst *FixedSizeTable) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	switch {
+	case atEOF && 0 == len(data):
+		return 0, nil, nil
+	case 0 < fst.recordLength:
+		if len(data) >= fst.recordLength {
+			return fst.recordLength, data[:fst.recordLength], nil
+		}
+	case nil != fst.terminator:
+		if p := indexUnit(data, fst.terminator, fst.unit); p >= 0 {
+			return p + len(fst.terminator), bytes.TrimSuffix(data[:p], fst.trimCR), nil
+		}
+	default:
+		return bufio.ScanLines(data, atEOF)
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// lastLine finds the last line of body, a terminator after it left out.
+func (fst *FixedSizeTable) lastLine(body []byte) (int, int) {
+	end := len(body)
+	switch {
+	case 0 < fst.recordLength:
+		return (end - 1) / fst.recordLength * fst.recordLength, end
+	case nil != fst.terminator:
+		end -= len(fst.terminator)
+		if end < 0 || !bytes.Equal(body[end:], fst.terminator) {
+			end = len(body)
+		} else if nil != fst.trimCR && bytes.HasSuffix(body[:end], fst.trimCR) {
+			end -= len(fst.trimCR)
+		}
+	case '\n' == body[end-1]:
+		end--
+		if 0 < end && '\r' == body[end-1] {
+			end--
+		}
+	}
+
+	start := 0
+	if 0 < end {
+		start = fst.FindLastNL(body[:end])
+	}
+	return start, end
+}
+
+// chunkSize is the size of the chunks of an input of size bytes, so they do not cut fixed length lines. The
+// chunks are counted from the end of the byte order mark, see chunkStart.
+func (fst *FixedSizeTable) chunkSize(size int64) int64 {
+	chunkSize := (size - int64(fst.bomLength)) / int64(fst.Cores)
+	if 0 == fst.recordLength {
+		if 1 < fst.unit { // Chunks start at whole code units
+			chunkSize -= chunkSize % int64(fst.unit)
+		}
+		return chunkSize
+	}
+
+	chunkSize -= chunkSize % int64(fst.recordLength)
+	if 0 == chunkSize {
+		fst.Cores = 1
+		return size
+	}
+	return chunkSize
+}
+
+// chunkStart is the offset of chunk k of chunkSize bytes, the first chunk holds the byte order mark on top.
+func (fst *FixedSizeTable) chunkStart(k int, chunkSize int64) int64 {
+	if 0 == k {
+		return 0
+	}
+	return int64(fst.bomLength) + int64(k)*chunkSize
+}
+
+// findFirstChunkEnd is FindLastNL for a chunk starting at the beginning of the input, past the byte order mark.
+func (fst *FixedSizeTable) findFirstChunkEnd(buf []byte) int {
+	bom := fst.bomLength
+	if len(buf) <= bom {
+		return fst.FindLastNL(buf)
+	}
+
+	p := fst.FindLastNL(buf[bom:])
+	if p < 0 {
+		return p
+	}
+	return bom + p
+}
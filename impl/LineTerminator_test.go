@@ -0,0 +1,201 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// TestTerminators frames header, body and footer with every LineTerminator, in the SourceEncoding.
+func TestTerminators(t *testing.T) {
+	tests := []struct {
+		name       string
+		terminator LineTerminator
+		eol        string
+		encoding   string
+	}{
+		{"crlf", TerminatorCRLF, "\r\n", ""},
+		{"lf", TerminatorLF, "\n", ""},
+		{"cr", TerminatorCR, "\r", ""},
+		{"nel", TerminatorNEL, "\u0085", ""},
+		{"lf latin-1", TerminatorLF, "\n", "iso8859-1"},
+		{"crlf windows-1252", TerminatorCRLF, "\r\n", "windows-1252"},
+		{"nel ebcdic", TerminatorNEL, "\u0085", "ibm037"},
+		{"fixed", TerminatorFixed, "", "iso8859-1"},
+		{"fixed ebcdic", TerminatorFixed, "", "ibm037"},
+	}
+
+	for _, tt := range tests {
+		lines := []string{"HDRé1", "abcde", "fghij", "klmnó", "TRLé1"}
+		if "" == tt.encoding { // The fields are bytes, ó takes two in UTF-8
+			lines[3] = "klmno"
+		}
+		text := strings.Join(lines, tt.eol) + tt.eol
+		data := []byte(text)
+		switch tt.encoding {
+		case "iso8859-1", "windows-1252":
+			data, _ = charmap.ISO8859_1.NewEncoder().Bytes(data)
+		case "ibm037":
+			data, _ = charmap.CodePage037.NewEncoder().Bytes(data)
+		}
+
+		for _, cores := range []int{1, 2} {
+			for _, engine := range []string{"file", "stream"} {
+				fst := &FixedSizeTable{Cores: cores, LineTerminator: tt.terminator, SourceEncoding: tt.encoding, HasHeader: true, HasFooter: true}
+				if err := chunkErrorConvert(engine, fst, data, chunkErrorRow()); nil != err {
+					t.Errorf("%s %s %d: %v", tt.name, engine, cores, err)
+					continue
+				}
+
+				var got []string
+				for _, record := range fst.Records[0] {
+					values := record.Column(0).(*array.String)
+					for i := 0; i < values.Len(); i++ {
+						got = append(got, values.Value(i))
+					}
+				}
+				if strings.Join(got, "|") != strings.Join(lines[1:4], "|") || lines[0] != fst.Header || lines[4] != fst.Footer {
+					t.Errorf("%s %s %d: header %q, body %q and footer %q", tt.name, engine, cores, fst.Header, got, fst.Footer)
+				}
+				fst.releaseRecords()
+			}
+		}
+	}
+}
+
+// TestDefaultTerminator cuts LF and CRLF lines with TerminatorDefault in any encoding.
+func TestDefaultTerminator(t *testing.T) {
+	crlf := benchData()
+	lf := bytes.ReplaceAll(crlf, []byte("\r\n"), []byte("\n"))
+
+	for _, encoding := range []string{"", "iso8859-1", "windows-1252", "utf-8"} {
+		for name, body := range map[string][]byte{"lf": lf, "crlf": crlf} {
+			eol := "\n"
+			if "crlf" == name {
+				eol = "\r\n"
+			}
+			data := append(append([]byte("HDR1"+eol), body...), "TRL1"+eol...)
+
+			for _, cores := range []int{1, 3} {
+				for _, engine := range []string{"file", "readerAt", "stream"} {
+					fst := &FixedSizeTable{Cores: cores, SourceEncoding: encoding, HasHeader: true, HasFooter: true, StreamChunkSize: 64 << 10}
+					if err := chunkErrorConvert(engine, fst, data, benchRow()); nil != err {
+						t.Errorf("%s %q %s %d: %v", name, encoding, engine, cores, err)
+						continue
+					}
+					if benchLines != fst.LinesParsed || "HDR1" != fst.Header || "TRL1" != fst.Footer {
+						t.Errorf("%s %q %s %d: %d lines parsed, header %q and footer %q", name, encoding, engine, cores, fst.LinesParsed, fst.Header, fst.Footer)
+					}
+					fst.releaseRecords()
+				}
+			}
+		}
+	}
+}
+
+func TestIndexUnit(t *testing.T) {
+	tests := []struct {
+		b     string
+		unit  int
+		index int
+		last  int
+	}{
+		{"a\nb\n", 1, 1, 3},
+		{"\x05\n\x00N\n\x00b\x00\n\x00", 2, 4, 8}, // \n\x00 at 1 is cut between two code units
+		{"\x05\n\x00N", 2, -1, -1},
+		{"", 2, -1, -1},
+	}
+
+	for _, tt := range tests {
+		sep := []byte("\n")
+		if 1 < tt.unit {
+			sep = []byte("\n\x00")
+		}
+		if got := indexUnit([]byte(tt.b), sep, tt.unit); got != tt.index {
+			t.Errorf("indexUnit(%q, %d) = %d, want %d", tt.b, tt.unit, got, tt.index)
+		}
+		if got := lastIndexUnit([]byte(tt.b), sep, tt.unit); got != tt.last {
+			t.Errorf("lastIndexUnit(%q, %d) = %d, want %d", tt.b, tt.unit, got, tt.last)
+		}
+	}
+}
+
+// TestUTF16 frames UTF-16 lines in code units with TerminatorDefault, ਅ一 holds the bytes of a LF cut in two.
+func TestUTF16(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 8, DestinField: arrow.Field{Name: "value", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+	var body []string
+	for i := 0; i < 500; i++ {
+		body = append(body, []string{"ਅ一ab", "cdef", "ghij"}[i%3])
+	}
+
+	tests := []struct {
+		encoding string
+		order    unicode.Endianness
+		bom      unicode.BOMPolicy
+	}{
+		{"utf-16", unicode.LittleEndian, unicode.UseBOM},
+		{"utf-16", unicode.BigEndian, unicode.UseBOM},
+		{"utf-16le", unicode.LittleEndian, unicode.UseBOM},
+		{"utf-16le", unicode.LittleEndian, unicode.IgnoreBOM},
+		{"utf-16be", unicode.BigEndian, unicode.IgnoreBOM},
+	}
+
+	for _, tt := range tests {
+		for _, eol := range []string{"\n", "\r\n"} {
+			text := "HDR" + eol + strings.Join(body, eol) + eol + "TRL" + eol
+			data, err := unicode.UTF16(tt.order, tt.bom).NewEncoder().Bytes([]byte(text))
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			for _, cores := range []int{1, 3} {
+				for _, engine := range []string{"file", "readerAt", "stream"} {
+					name := fmt.Sprintf("%s %v %q %s %d", tt.encoding, tt.bom, eol, engine, cores)
+					fst := &FixedSizeTable{Cores: cores, SourceEncoding: tt.encoding, HasHeader: true, HasFooter: true, StreamChunkSize: 1001}
+					if err := chunkErrorConvert(engine, fst, data, row); nil != err {
+						t.Errorf("%s: %v", name, err)
+						continue
+					}
+
+					var got []string
+					for _, record := range fst.Records[0] {
+						values := record.Column(0).(*array.String)
+						for i := 0; i < values.Len(); i++ {
+							got = append(got, values.Value(i))
+						}
+					}
+					if strings.Join(got, "|") != strings.Join(body, "|") || "HDR" != fst.Header || "TRL" != fst.Footer {
+						t.Errorf("%s: header %q, %d lines and footer %q", name, fst.Header, len(got), fst.Footer)
+					}
+					fst.releaseRecords()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// Lineage names the columns appended to every table to trace rows to their origin, empty names are left out.
+type Lineage struct {
+	SourceFile  string // SourceName of the table, as a dictionary
+	LineNumber  string // Line in the input counting from 1, the header included
+	ByteOffset  string // Offset of the line in the input, after decompression
+	ChunkNumber string
+	IngestedAt  string // Start of the conversion
+}
+
+var ingestedAtType = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+
+func (l Lineage) fields() []arrow.Field {
+	var fields []arrow.Field
+	if "" != l.SourceFile {
+		fields = append(fields, arrow.Field{Name: l.SourceFile, Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}})
+	}
+	if "" != l.LineNumber {
+		fields = append(fields, arrow.Field{Name: l.LineNumber, Type: arrow.PrimitiveTypes.Int64})
+	}
+	if "" != l.ByteOffset {
+		fields = append(fields, arrow.Field{Name: l.ByteOffset, Type: arrow.PrimitiveTypes.Int64})
+	}
+	if "" != l.ChunkNumber {
+		fields = append(fields, arrow.Field{Name: l.ChunkNumber, Type: arrow.PrimitiveTypes.Int32})
+	}
+	if "" != l.IngestedAt {
+		fields = append(fields, arrow.Field{Name: l.IngestedAt, Type: ingestedAtType})
+	}
+	return fields
+}
+
+// lineageBuilders are the builders of the lineage columns of one table, nil when left out.
+type lineageBuilders struct {
+	sourceFile  *array.BinaryDictionaryBuilder
+	lineNumber  *array.Int64Builder
+	byteOffset  *array.Int64Builder
+	chunkNumber *array.Int32Builder
+	ingestedAt  *array.TimestampBuilder
+}
+
+func (f *FixedSizeTableChunk) createLineageBuilders() {
+	l := f.FixedSizeTable.Lineage
+	if 0 == len(l.fields()) {
+		return
+	}
+
+	f.lineage = make([]lineageBuilders, len(f.RecordBuilder))
+	for t, rb := range f.RecordBuilder {
+		fieldNr := f.FixedSizeTable.TableColAmount[t]
+		next := func() array.Builder {
+			fieldNr++
+			return rb.Field(fieldNr - 1)
+		}
+
+		if "" != l.SourceFile {
+			f.lineage[t].sourceFile = next().(*array.BinaryDictionaryBuilder)
+		}
+		if "" != l.LineNumber {
+			f.lineage[t].lineNumber = next().(*array.Int64Builder)
+		}
+		if "" != l.ByteOffset {
+			f.lineage[t].byteOffset = next().(*array.Int64Builder)
+		}
+		if "" != l.ChunkNumber {
+			f.lineage[t].chunkNumber = next().(*array.Int32Builder)
+		}
+		if "" != l.IngestedAt {
+			f.lineage[t].ingestedAt = next().(*array.TimestampBuilder)
+		}
+	}
+}
+
+// appendLineage adds the lineage of a row from line of the chunk, starting at offset in the chunk.
+// Line numbers count within the chunk until collectChunks moves them.
+func (f *FixedSizeTableChunk) appendLineage(line int, offset int) {
+	fst := f.FixedSizeTable
+	for _, lb := range f.lineage {
+		if nil != lb.sourceFile {
+			lb.sourceFile.AppendString(fst.SourceName)
+		}
+		if nil != lb.lineNumber {
+			lb.lineNumber.Append(int64(line))
+		}
+		if nil != lb.byteOffset {
+			lb.byteOffset.Append(f.offset + int64(offset))
+		}
+		if nil != lb.chunkNumber {
+			lb.chunkNumber.Append(int32(f.Chunkr))
+		}
+		if nil != lb.ingestedAt {
+			lb.ingestedAt.Append(arrow.Timestamp(fst.ingestedAt.UnixMicro()))
+		}
+	}
+}
+
+// moveLineNumbers adds the lines of the chunks before to the line numbers of every chunk. The records are
+// not handed out yet, so the values are changed in place.
+func (fst *FixedSizeTable) moveLineNumbers(chunks int) {
+	if "" == fst.Lineage.LineNumber {
+		return
+	}
+
+	lines := 0
+	for k := 0; k < chunks; k++ {
+		fstc := &fst.TableChunks[k]
+		if 0 < lines {
+			for _, record := range fstc.Record {
+				indices := record.Schema().FieldIndices(fst.Lineage.LineNumber)
+				values := record.Column(indices[len(indices)-1]).(*array.Int64).Int64Values()
+				for i := range values {
+					values[i] += int64(lines)
+				}
+			}
+		}
+		lines += fstc.lines
+	}
+}
+
+func (fst *FixedSizeTable) startLineage() {
+	if "" == fst.Lineage.SourceFile {
+		fst.Lineage.SourceFile = fst.SourceFileColumn
+	}
+	if fst.ingestedAt.IsZero() {
+		fst.ingestedAt = time.Now()
+	}
+}
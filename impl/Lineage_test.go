@@ -0,0 +1,80 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+func TestLineage(t *testing.T) {
+	header := []byte("HEADER\r\n")
+	data := append(append([]byte{}, header...), benchData()...)
+	lineLength := int64(len(benchData()) / benchLines)
+	lineage := Lineage{SourceFile: "file", LineNumber: "line", ByteOffset: "offset", ChunkNumber: "chunk", IngestedAt: "ingested"}
+
+	for _, engine := range []string{"file", "readerAt", "stream"} {
+		fst := &FixedSizeTable{Cores: 4, HasHeader: true, SourceName: "feed.txt", Lineage: lineage, StreamChunkSize: 64 << 10}
+		start := time.Now()
+		if err := chunkErrorConvert(engine, fst, data, benchRow()); nil != err {
+			t.Fatalf("%s: %v", engine, err)
+		}
+
+		row, chunk := int64(0), int32(0)
+		for _, record := range fst.Records[0] {
+			column := func(name string) interface{} {
+				return record.Column(record.Schema().FieldIndices(name)[0])
+			}
+			lines := column("line").(*array.Int64)
+			offsets := column("offset").(*array.Int64)
+			chunks := column("chunk").(*array.Int32)
+			ingested := column("ingested").(*array.Timestamp)
+			for i := 0; i < int(record.NumRows()); i++ {
+				if want := row + 2; want != lines.Value(i) {
+					t.Fatalf("%s: row %d has line %d, want %d", engine, row, lines.Value(i), want)
+				}
+				if want := int64(len(header)) + row*lineLength; want != offsets.Value(i) {
+					t.Fatalf("%s: row %d has offset %d, want %d", engine, row, offsets.Value(i), want)
+				}
+				if c := chunks.Value(i); c < chunk || c >= int32(len(fst.TableChunks)) {
+					t.Fatalf("%s: row %d has chunk %d after %d", engine, row, c, chunk)
+				} else {
+					chunk = c
+				}
+				if at := ingested.Value(i).ToTime(ingestedAtType.Unit); at.Before(start.Truncate(time.Microsecond)) || at.After(time.Now()) {
+					t.Fatalf("%s: row %d ingested at %v", engine, row, at)
+				}
+				if "feed.txt" != dictionaryString(column("file"), i) {
+					t.Fatalf("%s: row %d from %s", engine, row, dictionaryString(column("file"), i))
+				}
+				row++
+			}
+		}
+		if benchLines != row {
+			t.Errorf("%s: %d rows of %d", engine, row, benchLines)
+		}
+		if 0 == chunk && "stream" != engine {
+			t.Errorf("%s: all rows in chunk 0", engine)
+		}
+
+		fst.releaseRecords()
+	}
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// CreateFixedSizeTableFromMmap converts the file memory mapped, the workers parse their regions without copying them.
+func CreateFixedSizeTableFromMmap(fst *FixedSizeTable, row *FixedRow, file *os.File) error {
+	return CreateFixedSizeTableFromMmapContext(context.Background(), fst, row, file)
+}
+
+func CreateFixedSizeTableFromMmapContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, file *os.File) error {
+	fi, err := file.Stat()
+	if nil != err {
+		return err
+	}
+
+	if "" == fst.SourceName {
+		fst.SourceName = file.Name()
+	}
+
+	// An empty file can not be mapped, and a compressed one is streamed
+	size := fi.Size()
+	head := make([]byte, magicLength)
+	n, _ := file.ReadAt(head, 0)
+	if 0 == size || (CompressionNone != fst.Compression && CompressionNone != fst.detectCompression(head[:n])) {
+		return CreateFixedSizeTableFromReaderAtContext(ctx, fst, row, file, size)
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if nil != err {
+		return err
+	}
+	defer syscall.Munmap(data)
+
+	head = data
+	if len(head) > sniffLength {
+		head = head[:sniffLength]
+	}
+	fst.sniff(head)
+
+	err = fst.prepare(ctx, row, size)
+	if nil != err {
+		return err
+	}
+
+	return ParalizeRegions(fst, func(start int64, end int64) ([]byte, error) {
+		return data[start:end], nil
+	}, size)
+}
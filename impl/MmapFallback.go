@@ -1,3 +1,5 @@
+//go:build !unix
+
 /*
  * MIT No Attribution
  *
@@ -20,37 +22,20 @@
 package impl
 
 import (
-	"github.com/apache/arrow/go/v13/arrow/array"
-	"strconv"
+	"context"
+	"os"
 )
 
-type ColumnBuilderInt64 struct {
-	fixedField    *FixedField
-	recordBuilder *array.RecordBuilder
-	fieldnr       int
-	values        []int64
-	valid         []bool
+// CreateFixedSizeTableFromMmap reads the file with ReadAt where there is no mmap.
+func CreateFixedSizeTableFromMmap(fst *FixedSizeTable, row *FixedRow, file *os.File) error {
+	return CreateFixedSizeTableFromMmapContext(context.Background(), fst, row, file)
 }
 
-func (c *ColumnBuilderInt64) ParseValue(name string) bool {
-
-	i, err := strconv.ParseInt(name, 10, 64)
+func CreateFixedSizeTableFromMmapContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, file *os.File) error {
+	fi, err := file.Stat()
 	if nil != err {
-		c.Nullify()
-		return false
+		return err
 	}
 
-	c.values = append(c.values, i)
-	c.valid = append(c.valid, true)
-	return true
-}
-
-func (c *ColumnBuilderInt64) FinishColumn() bool {
-	c.recordBuilder.Field(c.fieldnr).(*array.Int64Builder).AppendValues(c.values, c.valid)
-	return true
-}
-
-func (c *ColumnBuilderInt64) Nullify() {
-	c.values = append(c.values, 0)
-	c.valid = append(c.valid, false)
+	return CreateFixedSizeTableFromReaderAtContext(ctx, fst, row, file, fi.Size())
 }
@@ -0,0 +1,203 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"errors"
+	"github.com/apache/arrow/go/v13/arrow/decimal128"
+	"github.com/apache/arrow/go/v13/arrow/decimal256"
+	"strings"
+)
+
+// NumberLocale describes how numbers are written in a feed. A European "1.234.567,89" has DecimalSeparator ','
+// and GroupingSeparators "." (or ". " if blanks are used too). Zero DecimalSeparator means '.'.
+// Set it per field on FixedField.NumberLocale or for all numeric fields of a table on FixedSizeTable.NumberLocale.
+type NumberLocale struct {
+	DecimalSeparator   rune
+	GroupingSeparators string
+	CurrencySymbols    []string
+	Percent            bool // "12,5%" is 0.125
+	ParenNegative      bool // "(12,50)" is -12.50
+}
+
+var errNumber = errors.New("not a number")
+
+// Normalize rewrites a localized number into the form strconv understands, ie "(1.234,50 kr)" to "-1234.50".
+// Percent tells if a percent sign was stripped, the caller then divides by 100.
+// A trailing sign as in "123,45-" is accepted.
+func (l *NumberLocale) Normalize(value string) (string, bool, error) {
+	s := strings.TrimSpace(value)
+
+	for _, symbol := range l.CurrencySymbols {
+		s = strings.TrimSpace(strings.Replace(s, symbol, "", 1))
+	}
+
+	negative := false
+	if l.ParenNegative && strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	percent := false
+	if l.Percent {
+		if strings.HasSuffix(s, "%") {
+			percent = true
+			s = strings.TrimSpace(s[:len(s)-1])
+		} else if strings.HasPrefix(s, "%") {
+			percent = true
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = negative || '-' == s[0]
+		s = s[1:]
+	} else if strings.HasSuffix(s, "-") || strings.HasSuffix(s, "+") {
+		negative = negative || '-' == s[len(s)-1]
+		s = s[:len(s)-1]
+	}
+
+	decimalSeparator := l.DecimalSeparator
+	if 0 == decimalSeparator {
+		decimalSeparator = '.'
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 1)
+	if negative {
+		b.WriteByte('-')
+	}
+
+	digits := 0
+	seenDecimal := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			digits++
+		case r == decimalSeparator && !seenDecimal:
+			b.WriteByte('.')
+			seenDecimal = true
+		case !seenDecimal && strings.ContainsRune(l.GroupingSeparators, r):
+		default:
+			return "", false, errNumber
+		}
+	}
+
+	if 0 == digits {
+		return "", false, errNumber
+	}
+
+	return b.String(), percent, nil
+}
+
+// integerText applies the NumberLocale of the field, if any, to an integer value.
+func (f *FixedField) integerText(value string) (string, bool) {
+	if nil == f.NumberLocale {
+		return value, true
+	}
+
+	n, percent, err := f.NumberLocale.Normalize(value)
+	return n, nil == err && !percent
+}
+
+// floatText applies the NumberLocale of the field, if any, to a floating point value.
+func (f *FixedField) floatText(value string) (string, bool, bool) {
+	if nil == f.NumberLocale {
+		return value, false, true
+	}
+
+	n, percent, err := f.NumberLocale.Normalize(value)
+	return n, percent, nil == err
+}
+
+// ParseDecimal128 parses a plain decimal number like "-1234.50" into an unscaled value with the given scale.
+// Extra decimals are rounded half away from zero.
+func ParseDecimal128(value string, precision int32, scale int32) (decimal128.Num, error) {
+	// 38 digits is the maximum precision of a Decimal128
+	return parseDecimal(value, precision, scale, 38, decimal128.FromU64)
+}
+
+// ParseDecimal256 is ParseDecimal128 for a Decimal256, with up to 76 digits.
+func ParseDecimal256(value string, precision int32, scale int32) (decimal256.Num, error) {
+	return parseDecimal(value, precision, scale, 76, decimal256.FromU64)
+}
+
+type decimalNum[T any] interface {
+	Mul(T) T
+	Add(T) T
+	Negate() T
+	IncreaseScaleBy(int32) T
+	ReduceScaleBy(int32, bool) T
+	FitsInPrecision(int32) bool
+}
+
+func parseDecimal[T decimalNum[T]](value string, precision int32, scale int32, maxDigits int, fromU64 func(uint64) T) (T, error) {
+	var result, zero T
+
+	negative := false
+	if len(value) > 0 && (value[0] == '-' || value[0] == '+') {
+		negative = value[0] == '-'
+		value = value[1:]
+	}
+
+	result = fromU64(0)
+	ten := fromU64(10)
+	digits := 0
+	var fraction int32 = -1
+
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch >= '0' && ch <= '9':
+			digits++
+			result = result.Mul(ten).Add(fromU64(uint64(ch - '0')))
+			if fraction >= 0 {
+				fraction++
+			}
+		case ch == '.' && fraction < 0:
+			fraction = 0
+		default:
+			return zero, errNumber
+		}
+	}
+
+	if 0 == digits || digits > maxDigits || fraction-scale > int32(maxDigits) {
+		return zero, errNumber
+	}
+
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > scale {
+		result = result.ReduceScaleBy(fraction-scale, true)
+	} else if fraction < scale {
+		result = result.IncreaseScaleBy(scale - fraction)
+	}
+
+	if !result.FitsInPrecision(precision) {
+		return zero, errNumber
+	}
+
+	if negative {
+		result = result.Negate()
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+func TestNormalize(t *testing.T) {
+	european := &NumberLocale{DecimalSeparator: ',', GroupingSeparators: ". ", CurrencySymbols: []string{"kr", "€"}, Percent: true, ParenNegative: true}
+	plain := &NumberLocale{}
+
+	tests := []struct {
+		locale  *NumberLocale
+		value   string
+		want    string
+		percent bool
+		err     bool
+	}{
+		{european, "1.234.567,89", "1234567.89", false, false},
+		{european, "1 234 567,89", "1234567.89", false, false},
+		{european, "(1.234,50 kr)", "-1234.50", false, false},
+		{european, "€ 12,00", "12.00", false, false},
+		{european, "12,5%", "12.5", true, false},
+		{european, "%12,5", "12.5", true, false},
+		{european, "123,45-", "-123.45", false, false},
+		{european, "123,45+", "123.45", false, false},
+		{european, "  -7 ", "-7", false, false},
+		{plain, "1234.5", "1234.5", false, false},
+		{plain, "+1234.5", "1234.5", false, false},
+		{european, "", "", false, true},
+		{european, "kr", "", false, true},
+		{european, ",", "", false, true},
+		{european, "1,234,5", "", false, true},
+		{european, "12,3.4", "", false, true},
+		{european, "12a", "", false, true},
+		{plain, "12%", "", false, true},
+		{plain, "(12)", "", false, true},
+	}
+
+	for _, tt := range tests {
+		got, percent, err := tt.locale.Normalize(tt.value)
+		if tt.err {
+			if nil == err {
+				t.Errorf("Normalize(%q) = %q, want an error", tt.value, got)
+			}
+			continue
+		}
+		if nil != err || got != tt.want || percent != tt.percent {
+			t.Errorf("Normalize(%q) = %q, %v, %v, want %q, %v", tt.value, got, percent, err, tt.want, tt.percent)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value     string
+		precision int32
+		scale     int32
+		want      string
+		err       bool
+	}{
+		{"1234.50", 10, 2, "1234.5", false},
+		{"-1234.5", 10, 2, "-1234.5", false},
+		{"+12", 10, 2, "12", false},
+		{"1.005", 10, 2, "1.01", false},
+		{"1.004", 10, 2, "1", false},
+		{"-1.005", 10, 2, "-1.01", false},
+		{".5", 10, 2, "0.5", false},
+		{"12345", 5, 0, "12345", false},
+		{"", 10, 2, "", true},
+		{"-", 10, 2, "", true},
+		{".", 10, 2, "", true},
+		{"1.2.3", 10, 2, "", true},
+		{"1,5", 10, 2, "", true},
+		{"123456", 5, 0, "", true},
+		{"1234", 5, 2, "", true},
+		{"123456789012345678901234567890123456789", 38, 0, "", true},
+	}
+
+	for _, tt := range tests {
+		n, err := ParseDecimal128(tt.value, tt.precision, tt.scale)
+		if tt.err {
+			if nil == err {
+				t.Errorf("ParseDecimal128(%q, %d, %d) = %v, want an error", tt.value, tt.precision, tt.scale, n)
+			}
+			continue
+		}
+		if nil != err {
+			t.Errorf("ParseDecimal128(%q, %d, %d): %v", tt.value, tt.precision, tt.scale, err)
+			continue
+		}
+		want, _ := new(big.Rat).SetString(tt.want)
+		if got := scaled(n.BigInt(), tt.scale); 0 != got.Cmp(want) {
+			t.Errorf("ParseDecimal128(%q, %d, %d) = %s, want %s", tt.value, tt.precision, tt.scale, got.FloatString(int(tt.scale)), tt.want)
+		}
+	}
+
+	if _, err := ParseDecimal256("1234567890123456789012345678901234567890.5", 76, 1); nil != err {
+		t.Errorf("ParseDecimal256 of 41 digits: %v", err)
+	}
+}
+
+func TestTableNumberLocale(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 10, DestinField: arrow.Field{Name: "amount", Type: arrow.PrimitiveTypes.Float64, Nullable: true}, SourceType: arrow.PrimitiveTypes.Float64},
+	}}
+
+	for _, c := range []struct {
+		locale *NumberLocale
+		data   string
+	}{
+		{&NumberLocale{DecimalSeparator: ',', GroupingSeparators: "."}, "  1.234,50\n"},
+		{nil, "0001234.50\n"},
+	} {
+		fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF, NumberLocale: c.locale}
+		var reader io.Reader = strings.NewReader(c.data)
+		if err := CreateFixedSizeTableFromFile(fst, row, &reader, int64(len(c.data))); nil != err {
+			t.Fatal(err)
+		}
+		if got := fst.Records[0][0].Column(0).ValueStr(0); "1234.5" != got {
+			t.Errorf("%q parsed to %s, want 1234.5", c.data, got)
+		}
+		fst.releaseRecords()
+
+		if nil != row.FixedField[0].NumberLocale {
+			t.Fatal("the table NumberLocale was written into the row")
+		}
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package impl
+
+import (
+	"strconv"
+	"unsafe"
+)
+
+// Parsers for the []byte path, they work directly on the field bytes of the scanned line and never allocate.
+
+// bytesToString views b as a string without copying. Only for parsers that do not keep the string.
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// stringToBytes views s as a byte slice without copying. The bytes must never be written.
+func stringToBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
+		string
+		int
+	}{s, len(s)}))
+}
+
+func parseUintBytes(b []byte, max uint64) (uint64, bool) {
+	if 0 == len(b) {
+		return 0, false
+	}
+
+	// 19 digits never overflow, they are checked against max once
+	if len(b) <= 19 {
+		var n uint64
+		for _, ch := range b {
+			d := ch - '0'
+			if d > 9 {
+				return 0, false
+			}
+			n = n*10 + uint64(d)
+		}
+		return n, n <= max
+	}
+
+	var n uint64
+	for _, ch := range b {
+		if ch < '0' || ch > '9' {
+			return 0, false
+		}
+		d := uint64(ch - '0')
+		if n > (max-d)/10 {
+			return 0, false
+		}
+		n = n*10 + d
+	}
+	return n, true
+}
+
+func parseIntBytes(b []byte, min int64, max int64) (int64, bool) {
+	if 0 == len(b) {
+		return 0, false
+	}
+
+	negative := false
+	if '-' == b[0] || '+' == b[0] {
+		negative = '-' == b[0]
+		b = b[1:]
+	}
+
+	if negative {
+		n, ok := parseUintBytes(b, uint64(-(min+1))+1)
+		return -int64(n), ok
+	}
+
+	n, ok := parseUintBytes(b, uint64(max))
+	return int64(n), ok
+}
+
+// The powers of ten a float64 holds exactly.
+var float64Pow10 = [...]float64{1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16,
+	1e17, 1e18, 1e19, 1e20, 1e21, 1e22}
+
+// parseFloatBytes parses a decimal number like "-1234.5" or "1.2E-3". When the digits and the power of ten are both
+// exact in floating point, one multiplication or division rounds correctly. Other numbers, and "Inf" and "NaN",
+// go through strconv once their syntax is checked, so a bad value never allocates an error.
+func parseFloatBytes(b []byte, bitSize int) (float64, bool) {
+	s := b
+	negative := false
+	if 0 < len(s) && ('-' == s[0] || '+' == s[0]) {
+		negative = '-' == s[0]
+		s = s[1:]
+	}
+
+	var mantissa uint64
+	digits, exp := 0, 0
+	seenDigit, seenDot, truncated := false, false, false
+	i := 0
+scan:
+	for ; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case '0' <= ch && ch <= '9':
+			seenDigit = true
+			switch {
+			case 0 == mantissa && '0' == ch:
+			case digits < 19:
+				mantissa = mantissa*10 + uint64(ch-'0')
+				digits++
+			default:
+				truncated = true
+				continue
+			}
+			if seenDot {
+				exp--
+			}
+		case '.' == ch && !seenDot:
+			seenDot = true
+		default:
+			break scan
+		}
+	}
+
+	if !seenDigit {
+		switch bytesToString(s) {
+		case "Inf", "inf", "INF", "Infinity", "infinity", "NaN", "nan", "NAN":
+			f, err := strconv.ParseFloat(bytesToString(b), bitSize)
+			return f, nil == err
+		}
+		return 0, false
+	}
+
+	if i < len(s) {
+		if 'e' != s[i] && 'E' != s[i] {
+			return 0, false
+		}
+		e, ok := parseIntBytes(s[i+1:], -99999, 99999)
+		if !ok {
+			return 0, false
+		}
+		exp += int(e)
+	}
+
+	// Exact digits and powers of ten of float64, or of float32 so the result is not rounded twice
+	maxMantissa, maxExp := uint64(1)<<53, 22
+	if 32 == bitSize {
+		maxMantissa, maxExp = uint64(1)<<24, 10
+	}
+	if truncated || mantissa >= maxMantissa || exp < -maxExp || exp > maxExp {
+		f, err := strconv.ParseFloat(bytesToString(b), bitSize)
+		return f, nil == err
+	}
+
+	var f float64
+	switch {
+	case 32 == bitSize && exp < 0:
+		f = float64(float32(mantissa) / float32(float64Pow10[-exp]))
+	case 32 == bitSize:
+		f = float64(float32(mantissa) * float32(float64Pow10[exp]))
+	case exp < 0:
+		f = float64(mantissa) / float64Pow10[-exp]
+	default:
+		f = float64(mantissa) * float64Pow10[exp]
+	}
+	if negative {
+		f = -f
+	}
+	return f, true
+}
+
+// parseDateT1Days parses 2020-07-09-09.59.59 followed by fractionDigits digits after a separator, ie
+// 2000-05-13-09.00.00.000000 with 6 fraction digits, into days since 1970-01-01. Out of range months, days and
+// times roll over as in time.Date.
+func parseDateT1Days(b []byte, fractionDigits int) (int64, bool) {
+	length := 19
+	if fractionDigits > 0 {
+		length += 1 + fractionDigits
+	}
+	if len(b) < length {
+		return 0, false
+	}
+
+	century, ok1 := twoDigits(b[0:2])
+	year, ok2 := twoDigits(b[2:4])
+	month, ok3 := twoDigits(b[5:7])
+	day, ok4 := twoDigits(b[8:10])
+	hour, ok5 := twoDigits(b[11:13])
+	minute, ok6 := twoDigits(b[14:16])
+	second, ok7 := twoDigits(b[17:19])
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6 && ok7) {
+		return 0, false
+	}
+	if fractionDigits > 0 {
+		for _, ch := range b[20:length] {
+			if ch-'0' > 9 {
+				return 0, false
+			}
+		}
+	}
+
+	// The fraction is less than a second, it never rolls over into the next day
+	days := daysFromCivil(century*100+year, month, day)
+	return days + (hour*3600+minute*60+second)/86400, true
+}
+
+func twoDigits(b []byte) (int64, bool) {
+	d0, d1 := b[0]-'0', b[1]-'0'
+	return int64(d0)*10 + int64(d1), d0 <= 9 && d1 <= 9
+}
+
+// daysFromCivil gives the days since 1970-01-01 of a proleptic Gregorian date, month 0 and 13 and up roll over
+// into the years around.
+func daysFromCivil(year int64, month int64, day int64) int64 {
+	month--
+	year += month / 12
+	month %= 12
+	if month < 0 {
+		month += 12
+		year--
+	}
+
+	// March based years put the leap day last, see http://howardhinnant.github.io/date_algorithms.html
+	if month < 2 {
+		year--
+	}
+	era := year
+	if era < 0 {
+		era -= 399
+	}
+	era /= 400
+	yearOfEra := year - era*400
+	monthFromMarch := (month + 10) % 12
+	dayOfYear := (153*monthFromMarch+2)/5 + day - 1
+	dayOfEra := yearOfEra*365 + yearOfEra/4 - yearOfEra/100 + dayOfYear
+	return era*146097 + dayOfEra - 719468
+}
@@ -0,0 +1,95 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+func TestParseFloatBytes(t *testing.T) {
+	values := []string{
+		"0", "-0", "+0", "1", "-1", "0001234.500", "1234.5", ".5", "5.", "0.000001", "-0.1", "123456789012345",
+		"9007199254740993", "12345678901234567890123", "1.2e3", "1.2E-3", "-4e+2", "1e22", "1e23", "1e-22", "1e-23",
+		"1e400", "1e-400", "0.1e310", "Inf", "-Inf", "NaN", "3.4028235e38", "16777217", "0.3",
+		"", "-", "+", ".", "1..2", "1.2.3", "1e", "1e+", "e3", "12a", " 12", "12 ", "0x1p-2", "1_000",
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		values = append(values, strconv.FormatFloat(r.NormFloat64()*math.Pow10(r.Intn(30)-15), 'f', r.Intn(8), 64))
+	}
+
+	for _, bitSize := range []int{32, 64} {
+		for _, value := range values {
+			got, ok := parseFloatBytes([]byte(value), bitSize)
+			want, err := strconv.ParseFloat(value, bitSize)
+			if "0x1p-2" == value || "1_000" == value {
+				// strconv takes hex and underscores, fixed width feeds do not have them
+				err = strconv.ErrSyntax
+			}
+			switch {
+			case ok != (nil == err):
+				t.Errorf("parseFloatBytes(%q, %d) ok = %v, strconv error %v", value, bitSize, ok, err)
+			case ok && math.Float64bits(got) != math.Float64bits(want) && !(math.IsNaN(got) && math.IsNaN(want)):
+				t.Errorf("parseFloatBytes(%q, %d) = %v, want %v", value, bitSize, got, want)
+			}
+		}
+	}
+}
+
+func TestParseFloatBytesAllocations(t *testing.T) {
+	for _, value := range []string{"0001234.500", "-1.2E-3", "bad", "12345678901234567890123"} {
+		b := []byte(value)
+		if allocs := testing.AllocsPerRun(100, func() { parseFloatBytes(b, 64) }); 0 != allocs {
+			t.Errorf("parseFloatBytes(%q) allocates %v times", value, allocs)
+		}
+	}
+}
+
+func TestParseDateT1Days(t *testing.T) {
+	values := []string{"1970-01-01-00.00.00", "1969-12-31-23.59.59", "2000-02-29-12.00.00", "2024-01-31-00.00.00",
+		"0000-01-01-00.00.00", "9999-12-31-23.59.59", "2023-02-31-00.00.00", "2023-00-15-00.00.00", "2023-13-15-00.00.00",
+		"2023-99-99-99.99.99", "2023-12-31-24.00.00"}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		values = append(values, fmt.Sprintf("%04d-%02d-%02d-%02d.%02d.%02d", r.Intn(10000), r.Intn(14), r.Intn(33), r.Intn(26), r.Intn(61), r.Intn(61)))
+	}
+
+	for _, value := range values {
+		days, ok := parseDateT1Days([]byte(value+".123456"), 6)
+		var y, mo, d, h, mi, s int
+		fmt.Sscanf(value, "%4d-%2d-%2d-%2d.%2d.%2d", &y, &mo, &d, &h, &mi, &s)
+		want := arrow.Date32FromTime(time.Date(y, time.Month(mo), d, h, mi, s, 123456000, time.UTC))
+		if !ok || days != int64(want) {
+			t.Errorf("parseDateT1Days(%q) = %d, %v, want %d", value, days, ok, want)
+		}
+	}
+
+	for _, value := range []string{"", "2024-01-31", "2024-01-3x-00.00.00", "2024-01-31-00.00.00.12345x"} {
+		if _, ok := parseDateT1Days([]byte(value), 6); ok {
+			t.Errorf("parseDateT1Days(%q) succeeds", value)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"time"
+)
+
+// readRegionFunc gives the bytes from start up to end of the input.
+type readRegionFunc func(start int64, end int64) ([]byte, error)
+
+// The window looked back in for the line before a region boundary starts at regionWindow bytes and doubles.
+const regionWindow = 4096
+
+// CreateFixedSizeTableFromReaderAt converts size bytes of readerAt. Every worker finds the boundaries of
+// its own region, and reads and parses it independently of the others.
+func CreateFixedSizeTableFromReaderAt(fst *FixedSizeTable, row *FixedRow, readerAt io.ReaderAt, size int64) error {
+	return CreateFixedSizeTableFromReaderAtContext(context.Background(), fst, row, readerAt, size)
+}
+
+func CreateFixedSizeTableFromReaderAtContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, readerAt io.ReaderAt, size int64) error {
+	if CompressionNone != fst.Compression || fst.peeksHead() {
+		head := make([]byte, sniffLength)
+		n, _ := readerAt.ReadAt(head, 0)
+		if CompressionNone != fst.Compression && CompressionNone != fst.detectCompression(head[:n]) {
+			return CreateFixedSizeTableFromStreamContext(ctx, fst, row, io.NewSectionReader(readerAt, 0, size))
+		}
+		fst.sniff(head[:n])
+	}
+
+	err := fst.prepare(ctx, row, size)
+	if nil != err {
+		return err
+	}
+
+	return ParalizeRegions(fst, func(start int64, end int64) ([]byte, error) {
+		buf := make([]byte, end-start)
+		n, err := readerAt.ReadAt(buf, start)
+		if n == len(buf) {
+			return buf, nil
+		}
+		return nil, err
+	}, size)
+}
+
+// ParalizeRegions is ParalizeChunks with the reading done by the workers.
+func ParalizeRegions(fst *FixedSizeTable, read readRegionFunc, size int64) error {
+	if nil == fst.ctx {
+		fst.ctx = context.Background()
+	}
+	defer fst.dropChunkBytes()
+
+	chunkSize := fst.chunkSize(size)
+	fst.TableChunks = make([]FixedSizeTableChunk, fst.Cores)
+
+	for chunkNr := 0; chunkNr < fst.Cores; chunkNr++ {
+		fst.TableChunks[chunkNr] = FixedSizeTableChunk{FixedSizeTable: fst, Chunkr: chunkNr}
+		fst.wg.Add(1)
+		go fst.TableChunks[chunkNr].readRegion(read, chunkSize, size)
+	}
+	fst.wg.Wait()
+
+	err := fst.collectChunks(fst.Cores)
+	if nil != err {
+		return err
+	}
+
+	// The regions follow each other from the start to the end
+	if fst.CalcHash {
+		sha := sha256.New()
+		for _, tableChunk := range fst.TableChunks {
+			sha.Write(tableChunk.Bytes)
+		}
+		fst.Hash = sha.Sum(nil)
+	}
+
+	return nil
+}
+
+// readRegion reads the region of the chunk and processes it.
+func (fstc *FixedSizeTableChunk) readRegion(read readRegionFunc, chunkSize int64, size int64) {
+	fst := fstc.FixedSizeTable
+	last := fstc.Chunkr == fst.Cores-1
+
+	// The last region runs to the end, the last line may have no newline
+	nominalEnd := fst.chunkStart(fstc.Chunkr+1, chunkSize)
+
+	fstc.createColumBuilders(int(chunkSize))
+
+	startReadChunk := time.Now()
+	start, err := fst.regionBoundary(read, fst.chunkStart(fstc.Chunkr, chunkSize))
+	end := size
+	if nil == err && !last {
+		end, err = fst.regionBoundary(read, nominalEnd)
+	}
+	if nil == err && nil == fst.ctx.Err() {
+		fstc.Bytes, err = read(start, end)
+		fstc.offset = start
+	}
+	fstc.DurationReadChunk = time.Since(startReadChunk)
+
+	if nil != err || nil != fst.ctx.Err() {
+		if nil != err {
+			fstc.fail(0, err)
+		}
+		fst.wg.Done()
+		return
+	}
+
+	fstc.process(0 == fstc.Chunkr && fst.HasHeader, last && fst.HasFooter)
+}
+
+// regionBoundary is where the region starting at offset really starts, just after the last line before offset.
+func (fst *FixedSizeTable) regionBoundary(read readRegionFunc, offset int64) (int64, error) {
+	if 0 == offset || 0 < fst.recordLength { // Fixed length lines are not cut by the chunk size
+		return offset, nil
+	}
+
+	// A window reads up to the window before it, and the bytes of a line end that may span the two
+	overlap := int64(len(fst.terminator))
+	if overlap < 2 {
+		overlap = 2
+	}
+	overlap--
+
+	to := offset
+	for window := int64(regionWindow); ; window *= 2 {
+		from := offset - window
+		if from < 0 {
+			from = 0
+		}
+
+		buf, err := read(from, to)
+		if nil != err {
+			return 0, err
+		}
+
+		p := fst.FindLastNL(buf)
+		if p > 0 {
+			return from + int64(p), nil
+		}
+		if 0 == from {
+			return 0, nil
+		}
+		to = from + overlap
+	}
+}
+
+// dropChunkBytes lets go of the input, which may be unmapped after the conversion.
+func (fst *FixedSizeTable) dropChunkBytes() {
+	for i := range fst.TableChunks {
+		fst.TableChunks[i].Bytes = nil
+		fst.TableChunks[i].RawLine = nil
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+func TestRegionBoundary(t *testing.T) {
+	const offset = 100000
+	tests := []struct {
+		name     string
+		lineEnd  int // Where the CRLF starts, -1 for none
+		want     int64
+		maxBytes int
+	}{
+		{"first window", offset - 10, offset - 8, regionWindow},
+		{"spanning windows", offset - regionWindow - 1, offset - regionWindow + 1, 2*regionWindow + 1},
+		{"far back", 1000, 1002, offset + 16},
+		{"none", -1, 0, offset + 16},
+	}
+
+	for _, tt := range tests {
+		data := bytes.Repeat([]byte("a"), offset+100)
+		if 0 <= tt.lineEnd {
+			copy(data[tt.lineEnd:], "\r\n")
+		}
+
+		read := 0
+		fst := &FixedSizeTable{FindLastNL: FindLastNLCR}
+		got, err := fst.regionBoundary(func(start int64, end int64) ([]byte, error) {
+			read += int(end - start)
+			return data[start:end], nil
+		}, offset)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: boundary %d, want %d", tt.name, got, tt.want)
+		}
+		if read > tt.maxBytes {
+			t.Errorf("%s: read %d bytes, at most %d", tt.name, read, tt.maxBytes)
+		}
+	}
+}
+
+// TestReaderAtLongLines has lines longer than the first windows, so the boundaries are found in later ones.
+func TestReaderAtLongLines(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 10000, DestinField: arrow.Field{Name: "value", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+	var b bytes.Buffer
+	for i := 0; i < 20; i++ {
+		b.Write(bytes.Repeat([]byte{'a' + byte(i)}, 10000))
+		b.WriteString("\r\n")
+	}
+	data := b.Bytes()
+
+	for _, cores := range []int{1, 3, 7} {
+		fst := &FixedSizeTable{Cores: cores, FindLastNL: FindLastNLCR}
+		if err := CreateFixedSizeTableFromReaderAt(fst, row, bytes.NewReader(data), int64(len(data))); nil != err {
+			t.Fatalf("%d cores: %v", cores, err)
+		}
+		if 20 != fst.LinesParsed {
+			t.Errorf("%d cores: parsed %d lines of 20", cores, fst.LinesParsed)
+		}
+		fst.releaseRecords()
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"fmt"
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"sync"
+	"unsafe"
+)
+
+type ColumnBuilderFactory func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder
+
+// ColumnBuilders holds the factories of DefaultRegistry by source type. Factories assigned into it are used by
+// conversions without a Registry of their own, on top of DefaultRegistry.
+//
+// Deprecated: Register into DefaultRegistry, FixedSizeTable.Registry or CustomColumnBuilders instead, the map can
+// not be changed safely while conversions run.
+var ColumnBuilders map[arrow.Type]func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder
+
+// The factories ColumnBuilders started with, to tell which were assigned later.
+var initialColumnBuilders map[arrow.Type]unsafe.Pointer
+
+func initColumnBuilders() {
+	ColumnBuilders = make(map[arrow.Type]func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder)
+	initialColumnBuilders = make(map[arrow.Type]unsafe.Pointer)
+	for id, factory := range DefaultRegistry.builders {
+		ColumnBuilders[id] = factory
+		initialColumnBuilders[id] = funcPointer(factory)
+	}
+}
+
+// funcPointer tells func values apart, closures of the same func literal included.
+func funcPointer(f func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&f))
+}
+
+// assignedColumnBuilders gives a Registry with the factories assigned into ColumnBuilders on top of parent, parent
+// itself when there are none.
+func assignedColumnBuilders(parent *Registry) *Registry {
+	registry := parent
+	for id, factory := range ColumnBuilders {
+		if initial, ok := initialColumnBuilders[id]; nil == factory || ok && initial == funcPointer(factory) {
+			continue
+		}
+		if registry == parent {
+			registry = NewRegistry(parent)
+		}
+		registry.Register(id, factory)
+	}
+	return registry
+}
+
+// Registry maps fields to the factories of their ColumnBuilders. Field names are looked up before
+// source types, and whatever a Registry lacks is looked up in its parent. Safe for concurrent use.
+type Registry struct {
+	parent   *Registry
+	mutex    sync.RWMutex
+	builders map[arrow.Type]ColumnBuilderFactory
+	fields   map[string]ColumnBuilderFactory
+}
+
+// NewRegistry makes an empty Registry on top of parent, which may be nil.
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{parent: parent, builders: make(map[arrow.Type]ColumnBuilderFactory), fields: make(map[string]ColumnBuilderFactory)}
+}
+
+// Register sets the factory for all fields with the source type id.
+func (r *Registry) Register(id arrow.Type, factory ColumnBuilderFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.builders[id] = factory
+}
+
+// RegisterField sets the factory for the field with the destination name.
+func (r *Registry) RegisterField(name string, factory ColumnBuilderFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fields[name] = factory
+}
+
+// Lookup finds the factory for fixedField, the Builder of the field itself goes first.
+func (r *Registry) Lookup(fixedField *FixedField) (ColumnBuilderFactory, bool) {
+	if nil != fixedField.Builder {
+		return fixedField.Builder, true
+	}
+
+	for ; nil != r; r = r.parent {
+		r.mutex.RLock()
+		factory, ok := r.fields[fixedField.DestinField.Name]
+		if !ok {
+			factory, ok = r.builders[fixedField.SourceType.ID()]
+		}
+		r.mutex.RUnlock()
+
+		if ok {
+			return factory, true
+		}
+	}
+
+	return nil, false
+}
+
+// check that every field of row has a factory before any chunk is started.
+func (r *Registry) check(row *FixedRow) error {
+	for i := range row.FixedField {
+		if _, ok := r.Lookup(&row.FixedField[i]); !ok {
+			return fmt.Errorf("no column builder for field %s of type %s", row.FixedField[i].DestinField.Name, row.FixedField[i].SourceType)
+		}
+	}
+	return nil
+}
+
+// newRegistry gives the Registry of the conversion, CustomColumnBuilders on top of fst.Registry or DefaultRegistry.
+func (fst *FixedSizeTable) newRegistry() *Registry {
+	registry := fst.Registry
+	if nil == registry {
+		registry = assignedColumnBuilders(DefaultRegistry)
+	}
+
+	if 0 == len(fst.CustomColumnBuilders) {
+		return registry
+	}
+
+	registry = NewRegistry(registry)
+	for id, factory := range fst.CustomColumnBuilders {
+		registry.Register(id, factory)
+	}
+	return registry
+}
@@ -0,0 +1,58 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+func TestColumnBuilders(t *testing.T) {
+	if assignedColumnBuilders(DefaultRegistry) != DefaultRegistry {
+		t.Fatal("an unchanged ColumnBuilders layers a Registry")
+	}
+
+	original := ColumnBuilders[arrow.STRING]
+	defer func() { ColumnBuilders[arrow.STRING] = original }()
+	calls := 0
+	ColumnBuilders[arrow.STRING] = func(fixedField *FixedField, builder *array.RecordBuilder, columnsize int, fieldNr int, columnsizeCap int) *ColumnBuilder {
+		calls++
+		return original(fixedField, builder, columnsize, fieldNr, columnsizeCap)
+	}
+
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 3, DestinField: arrow.Field{Name: "s", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+	fst := &FixedSizeTable{Cores: 1, LineTerminator: TerminatorLF}
+	var reader = strings.NewReader("abc\n")
+	if err := CreateFixedSizeTableFromReaderAt(fst, row, reader, reader.Size()); nil != err {
+		t.Fatal(err)
+	}
+	defer fst.releaseRecords()
+
+	if 1 != calls {
+		t.Errorf("the factory assigned into ColumnBuilders made %d builders, want 1", calls)
+	}
+	if got := fst.Records[0][0].Column(0).ValueStr(0); "abc" != got {
+		t.Errorf("parsed %q, want abc", got)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Bytes of the input looked at by Sniff.
+const sniffLength = 8192
+
+// Sniffed is what the first bytes of the input tell.
+type Sniffed struct {
+	LineTerminator LineTerminator
+	BOM            int    // Length of the byte order mark, 0 for none
+	Encoding       string // Likely SourceEncoding, empty for plain ASCII
+	RecordLength   int    // Most common length of the lines in bytes, the terminator left out
+}
+
+// The terminators of an encoding family, in the order preferred on a tie.
+type terminatorBytes struct {
+	terminator LineTerminator
+	bytes      []byte
+}
+
+var (
+	asciiTerminators   = []terminatorBytes{{TerminatorCRLF, []byte("\r\n")}, {TerminatorLF, []byte("\n")}, {TerminatorCR, []byte("\r")}, {TerminatorNEL, []byte("\x85")}}
+	utf8Terminators    = []terminatorBytes{{TerminatorCRLF, []byte("\r\n")}, {TerminatorLF, []byte("\n")}, {TerminatorCR, []byte("\r")}, {TerminatorNEL, []byte("\xc2\x85")}}
+	ebcdicTerminators  = []terminatorBytes{{TerminatorCRLF, []byte("\x0d\x25")}, {TerminatorLF, []byte("\x25")}, {TerminatorCR, []byte("\x0d")}, {TerminatorNEL, []byte("\x15")}}
+	utf16leTerminators = []terminatorBytes{{TerminatorCRLF, []byte("\r\x00\n\x00")}, {TerminatorLF, []byte("\n\x00")}, {TerminatorCR, []byte("\r\x00")}, {TerminatorNEL, []byte("\x85\x00")}}
+	utf16beTerminators = []terminatorBytes{{TerminatorCRLF, []byte("\x00\r\x00\n")}, {TerminatorLF, []byte("\x00\n")}, {TerminatorCR, []byte("\x00\r")}, {TerminatorNEL, []byte("\x00\x85")}}
+)
+
+// Sniff guesses the line terminator, encoding and record length from the first bytes of the input.
+func Sniff(head []byte) Sniffed {
+	var s Sniffed
+	switch {
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		s.Encoding, s.BOM = "utf-8", 3
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		s.Encoding, s.BOM = "utf-16le", 2
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		s.Encoding, s.BOM = "utf-16be", 2
+	default:
+		s.Encoding = sniffEncoding(head)
+	}
+	head = head[s.BOM:]
+
+	terminators := asciiTerminators
+	switch s.Encoding {
+	case "utf-8":
+		terminators = utf8Terminators
+	case "ibm037":
+		terminators = ebcdicTerminators
+	case "utf-16le":
+		terminators = utf16leTerminators
+	case "utf-16be":
+		terminators = utf16beTerminators
+	}
+
+	// CRLF is also counted as CR and as LF
+	s.LineTerminator = TerminatorFixed
+	var found []byte
+	counts := make([]int, len(terminators))
+	best := 0
+	for i, t := range terminators {
+		counts[i] = bytes.Count(head, t.bytes)
+		if TerminatorLF == t.terminator || TerminatorCR == t.terminator {
+			counts[i] -= counts[0]
+		}
+		if counts[i] > best {
+			best = counts[i]
+			s.LineTerminator = t.terminator
+			found = t.bytes
+		}
+	}
+
+	if nil != found {
+		s.RecordLength = sniffRecordLength(head, found)
+	}
+	return s
+}
+
+// sniffEncoding tells EBCDIC, UTF-16 without byte order mark, UTF-8 and the single byte code pages apart.
+func sniffEncoding(head []byte) string {
+	if 0 == len(head) {
+		return ""
+	}
+
+	var ebcdic, high, c1, evenZeros, oddZeros int
+	for i, b := range head {
+		switch {
+		case 0x40 == b, 0x81 <= b && b <= 0x89, 0x91 <= b && b <= 0x99, 0xa2 <= b && b <= 0xa9,
+			0xc1 <= b && b <= 0xc9, 0xd1 <= b && b <= 0xd9, 0xe2 <= b && b <= 0xe9, 0xf0 <= b && b <= 0xf9:
+			ebcdic++
+		}
+		if b >= 0x80 {
+			high++
+			if b <= 0x9f {
+				c1++
+			}
+		}
+		if 0 == b {
+			if 0 == i%2 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+
+	switch {
+	case oddZeros > len(head)/4:
+		return "utf-16le"
+	case evenZeros > len(head)/4:
+		return "utf-16be"
+	case ebcdic > len(head)/2:
+		return "ibm037"
+	case 0 == high:
+		return ""
+	case validUTF8(head):
+		return "utf-8"
+	case 0 < c1: // Printable in Windows-1252, control characters in ISO 8859-1
+		return "windows-1252"
+	}
+	return "iso8859-1"
+}
+
+// validUTF8 allows a rune cut by the end of the head.
+func validUTF8(head []byte) bool {
+	for cut := 0; cut < utf8.UTFMax && cut <= len(head); cut++ {
+		if utf8.Valid(head[:len(head)-cut]) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffRecordLength finds the most common line length, the last line maybe cut by the end of the head left out.
+func sniffRecordLength(head []byte, terminator []byte) int {
+	lines := bytes.Split(head, terminator)
+	lengths := map[int]int{}
+	best := 0
+	for _, line := range lines[:len(lines)-1] {
+		lengths[len(line)]++
+		if lengths[len(line)] > lengths[best] || (lengths[len(line)] == lengths[best] && len(line) > best) {
+			best = len(line)
+		}
+	}
+	return best
+}
+
+// sniff sets what is not set from the head of the input with SniffInput, and finds the byte order mark.
+func (fst *FixedSizeTable) sniff(head []byte) {
+	if fst.SniffInput {
+		sniffed := Sniff(head)
+		fst.Sniffed = &sniffed
+		if TerminatorDefault == fst.LineTerminator && nil == fst.FindLastNL {
+			fst.LineTerminator = sniffed.LineTerminator
+		}
+		if "" == fst.SourceEncoding {
+			fst.SourceEncoding = sniffed.Encoding
+		}
+	}
+	fst.skipBOM(head)
+}
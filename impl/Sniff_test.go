@@ -0,0 +1,103 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encoded(text string, encoder interface{ Bytes([]byte) ([]byte, error) }) string {
+	b, err := encoder.Bytes([]byte(text))
+	if nil != err {
+		panic(err)
+	}
+	return string(b)
+}
+
+func TestSniff(t *testing.T) {
+	lines := strings.Repeat("abcdefgh\r\n", 3)
+	tests := []struct {
+		name string
+		head string
+		want Sniffed
+	}{
+		{"empty", "", Sniffed{LineTerminator: TerminatorFixed}},
+		{"crlf", "abcdefgh\r\nabcdefgh\r\nabc", Sniffed{LineTerminator: TerminatorCRLF, RecordLength: 8}},
+		{"lf", "abcdefgh\nabcdefgh\nabcdefg\nabc", Sniffed{LineTerminator: TerminatorLF, RecordLength: 8}},
+		{"cr", "abcdefgh\rabcdefgh\rabc", Sniffed{LineTerminator: TerminatorCR, RecordLength: 8}},
+		{"fixed", "abcdefghabcdefghabcdefgh", Sniffed{LineTerminator: TerminatorFixed}},
+		{"utf-8", "h\xc3\xa9llo\nh\xc3\xa9llo\n", Sniffed{LineTerminator: TerminatorLF, Encoding: "utf-8", RecordLength: 6}},
+		{"utf-8 bom", "\xef\xbb\xbfhello\r\nhello\r\n", Sniffed{LineTerminator: TerminatorCRLF, BOM: 3, Encoding: "utf-8", RecordLength: 5}},
+		{"utf-8 nel", "hello\xc2\x85hello\xc2\x85", Sniffed{LineTerminator: TerminatorNEL, Encoding: "utf-8", RecordLength: 5}},
+		{"latin-1", "h\xe9llo\nh\xe9llo\n", Sniffed{LineTerminator: TerminatorLF, Encoding: "iso8859-1", RecordLength: 5}},
+		{"windows-1252", "\x80 100\r\n\x80 200\r\n", Sniffed{LineTerminator: TerminatorCRLF, Encoding: "windows-1252", RecordLength: 5}},
+		{"ebcdic", encoded(strings.Repeat("HELLO WORLD 42\u0085", 3), charmap.CodePage037.NewEncoder()), Sniffed{LineTerminator: TerminatorNEL, Encoding: "ibm037", RecordLength: 14}},
+		{"utf-16le bom", encoded(lines, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder()), Sniffed{LineTerminator: TerminatorCRLF, BOM: 2, Encoding: "utf-16le", RecordLength: 16}},
+		{"utf-16be bom", encoded(lines, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder()), Sniffed{LineTerminator: TerminatorCRLF, BOM: 2, Encoding: "utf-16be", RecordLength: 16}},
+		{"utf-16le", encoded(lines, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder()), Sniffed{LineTerminator: TerminatorCRLF, Encoding: "utf-16le", RecordLength: 16}},
+		{"utf-16be", encoded(lines, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder()), Sniffed{LineTerminator: TerminatorCRLF, Encoding: "utf-16be", RecordLength: 16}},
+	}
+
+	for _, tt := range tests {
+		if got := Sniff([]byte(tt.head)); got != tt.want {
+			t.Errorf("%s: Sniff gives %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSniffInput converts LF lines in Latin-1 with nothing set but SniffInput.
+func TestSniffInput(t *testing.T) {
+	data := bytes.ReplaceAll(benchData(), []byte("\r\n"), []byte("\n"))
+
+	fst := &FixedSizeTable{Cores: 3, SniffInput: true}
+	var reader io.Reader = bytes.NewReader(data)
+	if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil != err {
+		t.Fatal(err)
+	}
+	defer fst.releaseRecords()
+
+	want := Sniffed{LineTerminator: TerminatorLF, Encoding: "iso8859-1", RecordLength: len(data)/benchLines - 1}
+	if nil == fst.Sniffed || want != *fst.Sniffed {
+		t.Errorf("sniffed %+v, want %+v", fst.Sniffed, want)
+	}
+	if TerminatorLF != fst.LineTerminator || "iso8859-1" != fst.SourceEncoding || benchLines != fst.LinesParsed {
+		t.Errorf("terminator %d, encoding %q and %d lines parsed", fst.LineTerminator, fst.SourceEncoding, fst.LinesParsed)
+	}
+	if got := fst.Records[0][0].Column(1).(*array.String).Value(0); "héllo" != strings.TrimRight(got, " ") {
+		t.Errorf("name %q, want héllo", got)
+	}
+
+	// What is set is kept
+	fst = &FixedSizeTable{Cores: 1, SniffInput: true, SourceEncoding: "windows-1252", LineTerminator: TerminatorLF}
+	reader = bytes.NewReader(data)
+	if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, int64(len(data))); nil != err {
+		t.Fatal(err)
+	}
+	if "windows-1252" != fst.SourceEncoding {
+		t.Errorf("encoding %q, want windows-1252", fst.SourceEncoding)
+	}
+	fst.releaseRecords()
+}
@@ -0,0 +1,182 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"io"
+	"time"
+)
+
+const defaultStreamChunkSize = 16 << 20
+
+// CreateFixedSizeTableFromStream converts a stream of unknown size like stdin, a pipe or an HTTP body, compressed
+// or not. The stream is read until EOF in chunks of StreamChunkSize cut at line ends, as many chunks as it takes,
+// and at most Cores of them are parsed at a time.
+func CreateFixedSizeTableFromStream(fst *FixedSizeTable, row *FixedRow, reader io.Reader) error {
+	return CreateFixedSizeTableFromStreamContext(context.Background(), fst, row, reader)
+}
+
+func CreateFixedSizeTableFromStreamContext(ctx context.Context, fst *FixedSizeTable, row *FixedRow, reader io.Reader) error {
+	reader, closeReader, err := fst.decompress(reader)
+	if nil != err {
+		return err
+	}
+	defer closeReader()
+
+	if fst.peeksHead() {
+		br := bufio.NewReaderSize(reader, sniffLength)
+		head, _ := br.Peek(sniffLength)
+		fst.sniff(head)
+		reader = br
+	}
+
+	err = fst.prepare(ctx, row, -1)
+	if nil != err {
+		return err
+	}
+
+	return ParalizeStream(fst, reader)
+}
+
+// ParalizeStream is ParalizeChunks for a reader of unknown size.
+func ParalizeStream(fst *FixedSizeTable, reader io.Reader) error {
+	if nil == fst.ctx {
+		fst.ctx = context.Background()
+	}
+
+	chunkSize := fst.StreamChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultStreamChunkSize
+	}
+
+	var chunks []*FixedSizeTableChunk
+	slots := make(chan struct{}, fst.Cores)
+	sha := sha256.New()
+
+	// A chunk is held back until the next is read, only then is it known whether it is the last one with the footer
+	var pending *FixedSizeTableChunk
+	dispatch := func(last bool) {
+		fstc := pending
+		pending = nil
+		fstc.Chunkr = len(chunks)
+		chunks = append(chunks, fstc)
+		fstc.createColumBuilders(len(fstc.Bytes))
+
+		slots <- struct{}{}
+		fst.wg.Add(1)
+		go func() {
+			defer func() { <-slots }()
+			fstc.process(0 == fstc.Chunkr && fst.HasHeader, last && fst.HasFooter)
+		}()
+	}
+
+	var carry []byte
+	var readErr error
+	var offset int64
+	for {
+		buf := make([]byte, len(carry)+chunkSize)
+		copy(buf, carry)
+
+		startReadChunk := time.Now()
+		n, err := readFull(fst.ctx, reader, buf[len(carry):])
+		durationReadChunk := time.Since(startReadChunk)
+		if nil != fst.ctx.Err() {
+			break
+		}
+
+		eof := io.EOF == err || io.ErrUnexpectedEOF == err
+		if nil != err && !eof {
+			readErr = err
+			break
+		}
+
+		if fst.CalcHash {
+			sha.Write(buf[len(carry) : len(carry)+n])
+		}
+		buf = buf[:len(carry)+n]
+
+		// At the end the last line may lack a line end, otherwise a chunk ends with the last line in it
+		p := len(buf)
+		if !eof {
+			if 0 == offset {
+				p = fst.findFirstChunkEnd(buf)
+			} else {
+				p = fst.FindLastNL(buf)
+			}
+			if p <= 0 { // Not even one line, read on
+				carry = buf
+				continue
+			}
+		}
+		carry = buf[p:]
+
+		// A short tail goes with the chunk before, so the footer lines are not torn apart
+		if eof && nil != pending && 0 < p && p < chunkSize/4 {
+			pending.Bytes = append(pending.Bytes, buf[:p]...)
+			break
+		}
+
+		if 0 < p {
+			if nil != pending {
+				dispatch(false)
+			}
+			pending = &FixedSizeTableChunk{FixedSizeTable: fst, Bytes: buf[:p], DurationReadChunk: durationReadChunk, offset: offset}
+			offset += int64(p)
+		}
+
+		if eof {
+			break
+		}
+	}
+
+	if nil != pending && nil == readErr && nil == fst.ctx.Err() {
+		dispatch(true)
+	}
+	fst.wg.Wait()
+
+	fst.TableChunks = make([]FixedSizeTableChunk, len(chunks))
+	for i, fstc := range chunks {
+		fst.TableChunks[i] = *fstc
+	}
+
+	if nil != readErr {
+		fst.releaseChunks(len(chunks))
+		return readErr
+	}
+
+	if 0 == len(chunks) { // Empty input still gives empty records
+		pending = &FixedSizeTableChunk{FixedSizeTable: fst}
+		dispatch(true)
+		fst.wg.Wait()
+		fst.TableChunks = []FixedSizeTableChunk{*chunks[0]}
+	}
+
+	err := fst.collectChunks(len(chunks))
+	if nil != err {
+		return err
+	}
+
+	if fst.CalcHash {
+		fst.Hash = sha.Sum(nil)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// pipe gives data through an io.Pipe in writes of n bytes, like stdin.
+func pipe(data []byte, n int) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		for 0 < len(data) {
+			if n > len(data) {
+				n = len(data)
+			}
+			w.Write(data[:n])
+			data = data[n:]
+		}
+		w.Close()
+	}()
+	return r
+}
+
+func TestStream(t *testing.T) {
+	data := benchData()
+	tests := []struct {
+		name      string
+		data      []byte
+		chunkSize int
+		lines     int
+	}{
+		{"chunks", data, 4096, benchLines},
+		{"one chunk", data, 0, benchLines},
+		{"no last line end", bytes.TrimSuffix(data, []byte("\r\n")), 4096, benchLines},
+		{"empty", nil, 4096, 0},
+	}
+
+	for _, tt := range tests {
+		fst := &FixedSizeTable{Cores: 2, StreamChunkSize: tt.chunkSize, CalcHash: true}
+		reader := pipe(tt.data, 1000)
+		if err := CreateFixedSizeTableFromFile(fst, benchRow(), &reader, -1); nil != err {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		id := int64(0)
+		for _, record := range fst.Records[0] {
+			for _, v := range record.Column(0).(*array.Int64).Int64Values() {
+				if v != id {
+					t.Errorf("%s: id %d, want %d", tt.name, v, id)
+				}
+				id++
+			}
+		}
+		if int64(tt.lines) != id || tt.lines != fst.LinesParsed {
+			t.Errorf("%s: %d rows and %d lines parsed of %d", tt.name, id, fst.LinesParsed, tt.lines)
+		}
+		if 4096 == tt.chunkSize && len(fst.TableChunks) < len(tt.data)/4096 {
+			t.Errorf("%s: %d chunks of %d bytes", tt.name, len(fst.TableChunks), tt.chunkSize)
+		}
+		if sum := sha256.Sum256(tt.data); !bytes.Equal(sum[:], fst.Hash) {
+			t.Errorf("%s: hash %x, want %x", tt.name, fst.Hash, sum)
+		}
+		fst.releaseRecords()
+	}
+}
+
+// TestStreamLongLines has lines longer than the chunks, they are carried into the next read.
+func TestStreamLongLines(t *testing.T) {
+	row := &FixedRow{FixedField: []FixedField{
+		{Len: 10000, DestinField: arrow.Field{Name: "value", Type: arrow.BinaryTypes.String, Nullable: true}, SourceType: arrow.BinaryTypes.String},
+	}}
+	var b bytes.Buffer
+	for i := 0; i < 20; i++ {
+		b.Write(bytes.Repeat([]byte{'a' + byte(i)}, 10000))
+		b.WriteString("\n")
+	}
+
+	fst := &FixedSizeTable{Cores: 3, StreamChunkSize: 4096, LineTerminator: TerminatorLF}
+	if err := CreateFixedSizeTableFromStream(fst, row, pipe(b.Bytes(), 3000)); nil != err {
+		t.Fatal(err)
+	}
+	i := 0
+	for _, record := range fst.Records[0] {
+		values := record.Column(0).(*array.String)
+		for j := 0; j < values.Len(); j++ {
+			if v := values.Value(j); 10000 != len(v) || 'a'+byte(i) != v[0] {
+				t.Errorf("line %d starts with %q and is %d bytes", i, v[0], len(v))
+			}
+			i++
+		}
+	}
+	if 20 != i {
+		t.Errorf("%d lines of 20", i)
+	}
+	fst.releaseRecords()
+}
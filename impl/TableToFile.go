@@ -32,8 +32,25 @@ import (
 func SaveToParquet(schema *arrow.Schema, record []arrow.Record, writer io.Writer, i int64) error {
 	var err error
 
-	props := parquet.NewWriterProperties(parquet.WithVersion(parquet.V2_LATEST), parquet.WithDictionaryDefault(false), parquet.WithCompression(compress.Codecs.Snappy))
+	opts := []parquet.WriterProperty{parquet.WithVersion(parquet.V2_LATEST), parquet.WithDictionaryDefault(false), parquet.WithCompression(compress.Codecs.Snappy)}
 	arrProps := pqarrow.DefaultWriterProps()
+
+	// Dictionary encoded columns keep their dictionary as parquet dictionary pages, the stored arrow schema
+	// makes them read back as dictionaries
+	hasDictionary := false
+	for _, field := range schema.Fields() {
+		if arrow.DICTIONARY == field.Type.ID() {
+			opts = append(opts, parquet.WithDictionaryFor(field.Name, true))
+			arrProps = pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())
+			hasDictionary = true
+		}
+	}
+
+	if hasDictionary {
+		return saveDictionaryRecordsToParquet(schema, record, writer, opts, arrProps, i)
+	}
+
+	props := parquet.NewWriterProperties(opts...)
 	tbl := array.NewTableFromRecords(schema, record)
 
 	err = pqarrow.WriteTable(tbl, writer, i, props, arrProps)
@@ -43,6 +60,46 @@ func SaveToParquet(schema *arrow.Schema, record []arrow.Record, writer io.Writer
 	return err
 }
 
+// Arrow 13 fails on dictionary arrays with an offset (see TestParquetDictionarySlice), so records are never sliced.
+// Whole records are buffered into row groups of up to rowGroupLength rows, 0 for the parquet default, a larger
+// record makes a row group of its own.
+func saveDictionaryRecordsToParquet(schema *arrow.Schema, record []arrow.Record, writer io.Writer, opts []parquet.WriterProperty, arrProps pqarrow.ArrowWriterProperties, rowGroupLength int64) error {
+	if rowGroupLength <= 0 {
+		rowGroupLength = parquet.DefaultMaxRowGroupLen
+	}
+	maxRows := rowGroupLength
+	batchSize := parquet.DefaultWriteBatchSize
+	for _, r := range record {
+		if r.NumRows() > maxRows {
+			maxRows = r.NumRows()
+		}
+		if r.NumRows() > batchSize {
+			batchSize = r.NumRows()
+		}
+	}
+	opts = append(opts, parquet.WithBatchSize(batchSize), parquet.WithMaxRowGroupLength(maxRows))
+
+	fw, err := pqarrow.NewFileWriter(schema, writer, parquet.NewWriterProperties(opts...), arrProps)
+	if nil != err {
+		return err
+	}
+
+	var rows int64
+	for _, r := range record {
+		if 0 < rows && rows+r.NumRows() > rowGroupLength {
+			fw.NewBufferedRowGroup()
+			rows = 0
+		}
+		err = fw.WriteBuffered(r)
+		if nil != err {
+			return err
+		}
+		rows += r.NumRows()
+	}
+
+	return fw.Close()
+}
+
 func saveToFeather(sc *arrow.Schema, table *array.TableReader, w io.Writer) {
 
 }
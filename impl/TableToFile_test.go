@@ -0,0 +1,125 @@
+/*
+ * MIT No Attribution
+ *
+ * Copyright 2021 Rickard Lundin (rickard@ignalina.dk)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+package impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/file"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+)
+
+var dictionarySchema = arrow.NewSchema([]arrow.Field{
+	{Name: "name", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}, Nullable: true},
+}, nil)
+
+// dictionaryRecords gives records of rows values each, value i of record r is dictionaryValue(r, i).
+func dictionaryRecords(records int, rows int) []arrow.Record {
+	var result []arrow.Record
+	for r := 0; r < records; r++ {
+		rb := array.NewRecordBuilder(memory.NewGoAllocator(), dictionarySchema)
+		b := rb.Field(0).(*array.BinaryDictionaryBuilder)
+		for i := 0; i < rows; i++ {
+			b.AppendString(dictionaryValue(r, i))
+		}
+		result = append(result, rb.NewRecord())
+		rb.Release()
+	}
+	return result
+}
+
+func dictionaryValue(r int, i int) string {
+	return fmt.Sprintf("name %d %d", r, i%7)
+}
+
+// TestParquetDictionarySlice shows why saveDictionaryRecordsToParquet never slices records. When it fails, Arrow
+// writes sliced dictionaries and SaveToParquet can write them through WriteTable like other columns.
+func TestParquetDictionarySlice(t *testing.T) {
+	records := dictionaryRecords(1, 1000)
+	defer records[0].Release()
+	slice := records[0].NewSlice(300, 1000)
+	defer slice.Release()
+
+	props := parquet.NewWriterProperties(parquet.WithDictionaryDefault(false), parquet.WithDictionaryFor("name", true))
+	var buf bytes.Buffer
+	fw, err := pqarrow.NewFileWriter(dictionarySchema, &buf, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := fw.Write(slice); nil == err {
+		t.Error("writing a sliced dictionary record succeeds")
+	}
+	fw.Close()
+}
+
+func TestSaveDictionaryToParquet(t *testing.T) {
+	records := dictionaryRecords(5, 1000)
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+
+	var buf bytes.Buffer
+	if err := SaveToParquet(dictionarySchema, records, &buf, 2500); nil != err {
+		t.Fatal(err)
+	}
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if nil != err {
+		t.Fatal(err)
+	}
+	// Whole records of 1000 rows in row groups of at most 2500
+	if 3 != reader.NumRowGroups() {
+		t.Errorf("%d row groups, want 3", reader.NumRowGroups())
+	}
+
+	fr, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+	if nil != err {
+		t.Fatal(err)
+	}
+	table, err := fr.ReadTable(context.Background())
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer table.Release()
+
+	if arrow.DICTIONARY != table.Schema().Field(0).Type.ID() {
+		t.Errorf("read back as %s, want a dictionary", table.Schema().Field(0).Type)
+	}
+	row := 0
+	for _, chunk := range table.Column(0).Data().Chunks() {
+		for i := 0; i < chunk.Len(); i++ {
+			if got, want := chunk.ValueStr(i), dictionaryValue(row/1000, row%1000); got != want {
+				t.Fatalf("row %d is %q, want %q", row, got, want)
+			}
+			row++
+		}
+	}
+	if 5000 != row {
+		t.Errorf("read %d rows, want 5000", row)
+	}
+}